@@ -16,9 +16,7 @@ package vanus
 
 import (
 	"context"
-	"fmt"
 	"io"
-	"strconv"
 	"time"
 
 	v2 "github.com/cloudevents/sdk-go/v2"
@@ -32,6 +30,9 @@ type Client interface {
 	Publisher(opts ...EventbusOption) Publisher
 	Subscriber(opts ...SubscriptionOption) Subscriber
 	Controller() Controller
+	// InvalidateMetadata drops the cached metadata of eventbuses, namespaces
+	// and subscriptions, so they are resolved again on next use.
+	InvalidateMetadata()
 	Disconnect() error
 }
 
@@ -39,6 +40,11 @@ type Publisher interface {
 	io.Closer
 	Eventbus() string
 	Publish(ctx context.Context, events ...*v2.Event) error
+	// PublishWithResults publishes events and returns their receipts, one per
+	// event in the same order, see WithPublishPositions.
+	PublishWithResults(ctx context.Context, events ...*v2.Event) ([]PublishResult, error)
+	// PublishAsync publishes events in background.
+	PublishAsync(ctx context.Context, events ...*v2.Event) *PublishFuture
 }
 
 type Subscriber interface {
@@ -65,39 +71,56 @@ type Event interface {
 }
 
 type Eventbus interface {
-	List(ctx context.Context) ([]*metapb.Eventbus, error)
+	List(ctx context.Context, opts ...ListOption) ([]*metapb.Eventbus, error)
+	// ListInfo is List of the metadata in EventbusInfo.
+	ListInfo(ctx context.Context, opts ...ListOption) ([]*EventbusInfo, error)
+	Iterate(ctx context.Context, opts ...ListOption) *Iterator[*metapb.Eventbus]
 	Get(ctx context.Context, opts ...EventbusOption) (*metapb.Eventbus, error)
+	// GetInfo is Get of the metadata in EventbusInfo.
+	GetInfo(ctx context.Context, opts ...EventbusOption) (*EventbusInfo, error)
+	// Create creates an eventbus with the properties of opts, e.g. WithLogNumber
+	// and WithDescription.
 	Create(ctx context.Context, opts ...EventbusOption) (*metapb.Eventbus, error)
+	// Ensure creates the eventbus if it doesn't exist, or verifies the existing
+	// one matches opts, ErrEventbusConflict is returned if it doesn't.
+	Ensure(ctx context.Context, opts ...EventbusOption) (*EventbusInfo, error)
 	Delete(ctx context.Context, opts ...EventbusOption) error
 	LookupOffset(ctx context.Context, timestamp time.Time, opts ...EventbusOption) (*proxypb.LookupOffsetResponse, error)
 	CheckHealth(ctx context.Context, opts ...EventbusOption) error
+	// Stats returns the statistics of the eventbus and its eventlogs.
+	Stats(ctx context.Context, opts ...EventbusOption) (*EventbusStats, error)
 }
 
 type Namespace interface {
+	List(ctx context.Context) ([]*metapb.Namespace, error)
 	Get(ctx context.Context, name string) (*metapb.Namespace, error)
+	Create(ctx context.Context, name, description string) (*metapb.Namespace, error)
+	Delete(ctx context.Context, name string) error
 }
 
 type Subscription interface {
-	List(ctx context.Context) ([]*metapb.Subscription, error)
+	List(ctx context.Context, opts ...ListOption) ([]*metapb.Subscription, error)
+	Iterate(ctx context.Context, opts ...ListOption) *Iterator[*metapb.Subscription]
+	// Watch streams the changes of the matched subscriptions until ctx is done,
+	// the existing ones are sent as created first. The controller is polled
+	// every WithWatchInterval, transient errors are retried at the next poll,
+	// and the others stop watching with a change of Err.
+	Watch(ctx context.Context, opts ...ListOption) (<-chan SubscriptionChange, error)
 	Get(ctx context.Context, opts ...SubscriptionOption) (*metapb.Subscription, error)
-	Update(ctx context.Context, request *ctrlpb.UpdateSubscriptionRequest) (*metapb.Subscription, error)
+	// Update updates the subscription of request.Id, or of opts if it's 0.
+	Update(ctx context.Context, request *ctrlpb.UpdateSubscriptionRequest, opts ...SubscriptionOption) (*metapb.Subscription, error)
 	Create(ctx context.Context, request *ctrlpb.SubscriptionRequest, opts ...SubscriptionOption) (*metapb.Subscription, error)
+	// Ensure creates the subscription if it doesn't exist, or verifies the
+	// existing one matches request, ErrSubscriptionConflict is returned if it
+	// doesn't. The subscription is identified by WithSubscriptionID if any, or
+	// by the name and eventbus of request.
+	Ensure(ctx context.Context, request *ctrlpb.SubscriptionRequest, opts ...SubscriptionOption) (*metapb.Subscription, error)
 	Delete(ctx context.Context, opts ...SubscriptionOption) error
+	// Offsets returns the committed offsets of the subscription per eventlog.
+	Offsets(ctx context.Context, opts ...SubscriptionOption) ([]SubscriptionOffset, error)
+	// Lag returns the number of events of the eventbus which are stored after
+	// the committed offsets of the subscription.
+	Lag(ctx context.Context, opts ...SubscriptionOption) (*SubscriptionLag, error)
 	Pause(ctx context.Context, opts ...SubscriptionOption) error
 	Resume(ctx context.Context, opts ...SubscriptionOption) error
 }
-
-type ID uint64
-
-func (id ID) Hex() string {
-	return fmt.Sprintf("%016X", id)
-}
-
-func NewID(id uint64) ID {
-	return ID(id)
-}
-
-func NewIDFromHex(id string) (ID, error) {
-	i, err := strconv.ParseInt(id, 16, 64)
-	return ID(i), err
-}
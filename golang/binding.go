@@ -0,0 +1,179 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vanus
+
+import (
+	// standard libraries.
+	"context"
+	"io"
+	"sync"
+
+	// third-party libraries.
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+)
+
+type BindingOption func(opt *bindingOptions)
+
+type bindingOptions struct {
+	eventbus     []EventbusOption
+	subscription []SubscriptionOption
+}
+
+// WithBindingEventbus sends events to the eventbus, by a Publisher of opts.
+func WithBindingEventbus(opts ...EventbusOption) BindingOption {
+	return func(opt *bindingOptions) {
+		opt.eventbus = opts
+	}
+}
+
+// WithBindingSubscription receives events of the subscription, by a
+// Subscriber of opts in active mode.
+func WithBindingSubscription(opts ...SubscriptionOption) BindingOption {
+	return func(opt *bindingOptions) {
+		opt.subscription = opts
+	}
+}
+
+// Binding is a protocol binding of the CloudEvents SDK, so Vanus can be the
+// transport of cloudevents.NewClient:
+//
+//	p := vanus.NewBinding(c, vanus.WithBindingEventbus(vanus.WithEventbus("default", "orders")))
+//	client, err := cloudevents.NewClient(p)
+//
+// A message is acknowledged if it's finished with an ACK result, e.g. the
+// receiver function of the client returns nil, and redelivered otherwise.
+type Binding struct {
+	publisher  Publisher
+	subscriber Subscriber
+
+	incoming  chan Message
+	closeC    chan struct{}
+	closeOnce sync.Once
+}
+
+// Make sure Binding implements the interfaces of the CloudEvents SDK.
+var (
+	_ protocol.Sender   = (*Binding)(nil)
+	_ protocol.Receiver = (*Binding)(nil)
+	_ protocol.Opener   = (*Binding)(nil)
+	_ protocol.Closer   = (*Binding)(nil)
+)
+
+// NewBinding returns a binding which sends to the eventbus of
+// WithBindingEventbus, and receives from the subscription of
+// WithBindingSubscription, either of which may be unset.
+func NewBinding(c Client, opts ...BindingOption) *Binding {
+	o := bindingOptions{}
+	for _, apply := range opts {
+		apply(&o)
+	}
+	p := &Binding{
+		incoming: make(chan Message),
+		closeC:   make(chan struct{}),
+	}
+	if o.eventbus != nil {
+		p.publisher = c.Publisher(o.eventbus...)
+	}
+	if o.subscription != nil {
+		// The subscriber is closed with the binding, so it's not shared.
+		p.subscriber = uncachedSubscriber(c, append(o.subscription, WithActiveMode(true))...)
+	}
+	return p
+}
+
+// Send publishes the event of m.
+func (p *Binding) Send(ctx context.Context, m binding.Message, transformers ...binding.Transformer) (err error) {
+	defer func() {
+		_ = m.Finish(err)
+	}()
+	if p.publisher == nil {
+		return ErrBindingNoEventbus
+	}
+	e, err := binding.ToEvent(ctx, m, transformers...)
+	if err != nil {
+		return err
+	}
+	return p.publisher.Publish(ctx, e)
+}
+
+// OpenInbound receives events of the subscription until ctx is done.
+func (p *Binding) OpenInbound(ctx context.Context) error {
+	if p.subscriber == nil {
+		return ErrBindingNoSubscription
+	}
+	errC := make(chan error, 1)
+	go func() {
+		errC <- p.subscriber.Listen(func(_ context.Context, msgs ...Message) error {
+			for _, msg := range msgs {
+				select {
+				case p.incoming <- msg:
+				case <-p.closeC:
+					msg.Failed(io.EOF)
+				case <-ctx.Done():
+					// Nobody receives after OpenInbound returns.
+					msg.Failed(ctx.Err())
+				}
+			}
+			return nil
+		})
+	}()
+	select {
+	case <-ctx.Done():
+		_ = p.subscriber.Close()
+		return nil
+	case <-p.closeC:
+		return nil
+	case err := <-errC:
+		if err == io.EOF {
+			return nil
+		}
+		return err
+	}
+}
+
+// Receive returns the next event, the caller must finish it to acknowledge.
+func (p *Binding) Receive(ctx context.Context) (binding.Message, error) {
+	select {
+	case <-ctx.Done():
+		return nil, io.EOF
+	case <-p.closeC:
+		return nil, io.EOF
+	case msg := <-p.incoming:
+		return binding.WithFinish(binding.ToMessage(msg.GetEvent()), func(err error) {
+			if protocol.IsACK(err) {
+				msg.Success()
+			} else {
+				msg.Failed(err)
+			}
+		}), nil
+	}
+}
+
+func (p *Binding) Close(context.Context) error {
+	p.closeOnce.Do(func() {
+		close(p.closeC)
+	})
+	var err error
+	if p.subscriber != nil {
+		err = p.subscriber.Close()
+	}
+	if p.publisher != nil {
+		if e := p.publisher.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
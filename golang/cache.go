@@ -0,0 +1,151 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vanus
+
+import (
+	// standard libraries.
+	stderrors "errors"
+	"sync"
+	"time"
+
+	// first-party libraries.
+	"github.com/vanus-labs/vanus/api/errors"
+)
+
+// DefaultMetadataTTL is the time to live of cached metadata if
+// ClientOptions.MetadataTTL is 0.
+const DefaultMetadataTTL = time.Minute
+
+const (
+	cacheEventbus     = "eventbus/"
+	cacheNamespace    = "namespace/"
+	cacheSubscription = "subscription/"
+)
+
+type cacheEntry struct {
+	value    interface{}
+	expireAt time.Time
+}
+
+// metadataCache caches the resolution of names to metadata of eventbuses,
+// namespaces and subscriptions, shared by all controllers, publishers and
+// subscribers of a client. A nil cache caches nothing.
+type metadataCache struct {
+	ttl     time.Duration
+	mu      sync.RWMutex
+	entries map[string]cacheEntry
+}
+
+// newMetadataCache returns nil if ttl is negative, which disables caching.
+func newMetadataCache(ttl time.Duration) *metadataCache {
+	if ttl < 0 {
+		return nil
+	}
+	if ttl == 0 {
+		ttl = DefaultMetadataTTL
+	}
+	return &metadataCache{
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+func (c *metadataCache) get(key string) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+	if !ok || time.Now().After(entry.expireAt) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// set caches value under all keys.
+func (c *metadataCache) set(value interface{}, keys ...string) {
+	if c == nil {
+		return
+	}
+	entry := cacheEntry{value: value, expireAt: time.Now().Add(c.ttl)}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		c.entries[key] = entry
+	}
+}
+
+func (c *metadataCache) invalidate(keys ...string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		delete(c.entries, key)
+	}
+}
+
+// invalidateFunc removes the entries matched by f, e.g. all names of an ID.
+func (c *metadataCache) invalidateFunc(f func(key string, value interface{}) bool) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, entry := range c.entries {
+		if f(key, entry.value) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func (c *metadataCache) clear() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.entries = make(map[string]cacheEntry)
+	c.mu.Unlock()
+}
+
+// eventbusCacheKey returns the key of the eventbus by name, or by ID if the
+// name is unset.
+func eventbusCacheKey(namespace, name string, id uint64) string {
+	if name == "" {
+		namespace = ""
+	} else {
+		id = 0
+	}
+	return cacheEventbus + eventbusOptions{namespace: namespace, eventbusName: name, eventbusID: id}.key()
+}
+
+func subscriptionCacheKey(namespace, name string) string {
+	return cacheSubscription + subscriptionOptions{namespace: namespace, subscriptionName: name}.key()
+}
+
+// isNotFound returns whether err means the resolved metadata is stale, e.g.
+// the eventbus was deleted and recreated with the same name.
+func isNotFound(err error) bool {
+	if err == nil {
+		return false
+	}
+	if stderrors.Is(err, ErrEventbusNotFound) || stderrors.Is(err, ErrSubscriptionNotFound) {
+		return true
+	}
+	et, ok := errors.FromError(err)
+	return ok && et.Code >= errors.ErrorCodeResourceNotFound && et.Code < errors.ErrorCodeResourceNotFound+100
+}
@@ -0,0 +1,315 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vanus
+
+import (
+	// standard libraries.
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	// third-party libraries.
+	v2 "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	// extClaimCheck is the reference of the data which is stored in a BlobStore.
+	extClaimCheck = "claimcheck"
+
+	fileBlobScheme = "file"
+	s3BlobScheme   = "s3"
+)
+
+// BlobStore stores the data of oversized events for the claim-check pattern.
+type BlobStore interface {
+	// Put stores data by key, and returns the reference carried by the event.
+	Put(ctx context.Context, key string, data []byte) (ref string, err error)
+	Get(ctx context.Context, ref string) ([]byte, error)
+	Delete(ctx context.Context, ref string) error
+}
+
+type fileBlobStore struct {
+	dir string
+}
+
+// NewFileBlobStore returns a BlobStore which stores blobs in dir, which should
+// be shared by publishers and subscribers, e.g. a network filesystem.
+func NewFileBlobStore(dir string) (BlobStore, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &fileBlobStore{dir: dir}, nil
+}
+
+func (s *fileBlobStore) Put(_ context.Context, key string, data []byte) (string, error) {
+	p := filepath.Join(s.dir, filepath.FromSlash(path.Clean("/"+key)))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(p, data, 0o644); err != nil {
+		return "", err
+	}
+	return (&url.URL{Scheme: fileBlobScheme, Path: filepath.ToSlash(p)}).String(), nil
+}
+
+func (s *fileBlobStore) Get(_ context.Context, ref string) ([]byte, error) {
+	p, err := s.path(ref)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(p)
+}
+
+func (s *fileBlobStore) Delete(_ context.Context, ref string) error {
+	p, err := s.path(ref)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *fileBlobStore) path(ref string) (string, error) {
+	u, err := url.Parse(ref)
+	if err != nil || u.Scheme != fileBlobScheme {
+		return "", fmt.Errorf("%w: %s", ErrInvalidBlobRef, ref)
+	}
+	p := filepath.FromSlash(u.Path)
+	if rel, err := filepath.Rel(s.dir, p); err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("%w: %s is out of %s", ErrInvalidBlobRef, ref, s.dir)
+	}
+	return p, nil
+}
+
+// S3Config configures the BlobStore of S3 compatible services, e.g. MinIO.
+type S3Config struct {
+	// Endpoint is the URL of the service, e.g. https://s3.us-west-2.amazonaws.com
+	// or http://127.0.0.1:9000. Buckets are addressed in path style.
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// Prefix is prepended to the keys of objects.
+	Prefix string
+	Client *http.Client
+}
+
+type s3BlobStore struct {
+	cfg      S3Config
+	endpoint *url.URL
+}
+
+// NewS3BlobStore returns a BlobStore which stores blobs in an S3 bucket, the
+// requests are signed by AWS Signature Version 4.
+func NewS3BlobStore(cfg S3Config) (BlobStore, error) {
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("%w: bucket is required", ErrInvalidArguments)
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	return &s3BlobStore{cfg: cfg, endpoint: u}, nil
+}
+
+func (s *s3BlobStore) Put(ctx context.Context, key string, data []byte) (string, error) {
+	key = path.Join(s.cfg.Prefix, key)
+	resp, err := s.do(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	return (&url.URL{Scheme: s3BlobScheme, Host: s.cfg.Bucket, Path: "/" + key}).String(), nil
+}
+
+func (s *s3BlobStore) Get(ctx context.Context, ref string) ([]byte, error) {
+	key, err := s.key(ref)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+func (s *s3BlobStore) Delete(ctx context.Context, ref string) error {
+	key, err := s.key(ref)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (s *s3BlobStore) key(ref string) (string, error) {
+	u, err := url.Parse(ref)
+	if err != nil || u.Scheme != s3BlobScheme || u.Host != s.cfg.Bucket {
+		return "", fmt.Errorf("%w: %s", ErrInvalidBlobRef, ref)
+	}
+	return strings.TrimPrefix(u.Path, "/"), nil
+}
+
+func (s *s3BlobStore) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	u := *s.endpoint
+	u.Path = path.Join("/", u.Path, s.cfg.Bucket, key)
+	u.RawPath = s3EscapePath(u.Path)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	s.sign(req, body, time.Now().UTC())
+
+	resp, err := s.cfg.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: %s", ErrBlobNotFound, key)
+		}
+		return nil, fmt.Errorf("s3 %s %s responded %s: %s", method, key, resp.Status, msg)
+	}
+	return resp, nil
+}
+
+// sign signs the request by AWS Signature Version 4.
+func (s *s3BlobStore) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256.Sum256(body)
+	payload := hex.EncodeToString(payloadHash[:])
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payload)
+	if s.cfg.AccessKeyID == "" {
+		return
+	}
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + payload + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payload,
+	}, "\n")
+	scope := date + "/" + s.cfg.Region + "/s3/aws4_request"
+	canonicalHash := sha256.Sum256([]byte(canonical))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretAccessKey), date)
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3EscapePath escapes everything but the unreserved characters and '/'.
+func s3EscapePath(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// checkInEvent returns a copy of e whose data is stored in the store if it's
+// larger than threshold bytes.
+func checkInEvent(ctx context.Context, e *v2.Event, store BlobStore, prefix string, threshold int) (*v2.Event, error) {
+	if len(e.Data()) <= threshold {
+		return e, nil
+	}
+	ref, err := store.Put(ctx, path.Join(prefix, uuid.NewString()), e.Data())
+	if err != nil {
+		return nil, err
+	}
+	out := e.Clone()
+	out.DataEncoded = nil
+	out.SetExtension(extClaimCheck, ref)
+	return &out, nil
+}
+
+func claimCheckRef(e *v2.Event) string {
+	ref, _ := e.Extensions()[extClaimCheck].(string)
+	return ref
+}
+
+// checkOutEvent loads the data of e from the store in place, and returns the
+// reference of the data.
+func checkOutEvent(ctx context.Context, e *v2.Event, store BlobStore) (string, error) {
+	ref := claimCheckRef(e)
+	if ref == "" {
+		return "", nil
+	}
+	data, err := store.Get(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	e.DataEncoded = data
+	e.SetExtension(extClaimCheck, nil)
+	return ref, nil
+}
@@ -18,11 +18,15 @@ import (
 	// standard libraries.
 	"context"
 	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	// third-party libraries.
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
 
 	// first-party libraries.
 	"github.com/vanus-labs/vanus/api/credentials"
@@ -30,8 +34,36 @@ import (
 )
 
 type ClientOptions struct {
+	// Endpoint is the address of the proxy, or a comma-separated list of them.
 	Endpoint string
-	Token    string
+	// Endpoints are more addresses of proxies. A single endpoint may also be a
+	// gRPC target like "dns:///proxy:8080", which resolves to many addresses.
+	Endpoints []string
+	Token     string
+	// SchemaRegistry validates events with dataschema on publishing, and
+	// resolves the schemas of received messages.
+	SchemaRegistry SchemaRegistry
+	// Compression is the gRPC compressor of requests, e.g. CompressionGzip,
+	// CompressionZstd or CompressionSnappy. The proxy must have registered the
+	// same compressor, or every call fails with Unimplemented, so zstd and
+	// snappy need a proxy built with them. WithPayloadCompression needs no
+	// support of the servers.
+	Compression string
+	// MetadataTTL is how long the client caches the resolution of eventbus,
+	// namespace and subscription names, DefaultMetadataTTL if it's 0, and a
+	// negative value disables the cache.
+	MetadataTTL time.Duration
+	// LoadBalancing is the policy to pick an endpoint for each request,
+	// LoadBalancingRoundRobin if it's empty, or LoadBalancingLeastRequest.
+	LoadBalancing string
+	// PoolSize is the number of connections shared by publishers and
+	// subscribers, each of which connects to all endpoints, 1 if it's 0.
+	PoolSize int
+	// HealthCheck enables the gRPC health checking protocol of endpoints,
+	// those that aren't serving are ejected until they recover.
+	HealthCheck bool
+	// HealthCheckService is the service checked if HealthCheck is enabled.
+	HealthCheckService string
 }
 
 type streamState string
@@ -44,27 +76,43 @@ var (
 
 type client struct {
 	endpoint        string
+	schemaRegistry  SchemaRegistry
 	controller      proxypb.ControllerProxyClient
+	cache           *metadataCache
 	subscriberCache sync.Map
 	subMu           sync.RWMutex
 	pubMu           sync.RWMutex
 	conn            *grpc.ClientConn
+	pool            []*grpc.ClientConn
+	next            uint64
 }
 
 func Connect(options *ClientOptions) (Client, error) {
-	if options.Endpoint == "" {
+	endpoints := endpointsOf(options)
+	if len(endpoints) == 0 {
 		// log.Error(context.Background(), "endpoint is required for client", nil)
 		return nil, errors.New("endpoint is required for client")
 	}
+	sc, err := serviceConfig(options)
+	if err != nil {
+		return nil, err
+	}
 	opts := []grpc.DialOption{
 		grpc.WithUnaryInterceptor(UnaryClientInterceptor()),
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultServiceConfig(sc),
+	}
+	if options.Compression != "" {
+		if encoding.GetCompressor(options.Compression) == nil {
+			return nil, fmt.Errorf("%w: %s", ErrCompressorNotFound, options.Compression)
+		}
+		opts = append(opts, grpc.WithDefaultCallOptions(grpc.UseCompressor(options.Compression)))
 	}
 	if options.Token != "" {
 		opts = append(opts, grpc.WithPerRPCCredentials(
 			credentials.NewVanusPerRPCCredentials(options.Token)))
 	}
-	conn, err := grpc.Dial(options.Endpoint, opts...)
+	pool, err := dialPool(endpoints, options.PoolSize, opts...)
 	if err != nil {
 		//log.Error(context.Background(), "grpc dial error", map[string]interface{}{
 		//	log.KeyError: err,
@@ -72,14 +120,32 @@ func Connect(options *ClientOptions) (Client, error) {
 		return nil, err
 	}
 	return &client{
-		conn:       conn,
-		endpoint:   options.Endpoint,
-		controller: proxypb.NewControllerProxyClient(conn),
+		conn:           pool[0],
+		pool:           pool,
+		endpoint:       endpoints[0],
+		schemaRegistry: options.SchemaRegistry,
+		controller:     proxypb.NewControllerProxyClient(pool[0]),
+		cache:          newMetadataCache(options.MetadataTTL),
 	}, nil
 }
 
+func (c *client) InvalidateMetadata() {
+	c.cache.clear()
+}
+
 func (c *client) Disconnect() error {
-	return nil
+	var err error
+	for _, conn := range c.pool {
+		if e := conn.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// connection returns the connections of the pool in turn.
+func (c *client) connection() *grpc.ClientConn {
+	return c.pool[atomic.AddUint64(&c.next, 1)%uint64(len(c.pool))]
 }
 
 func (c *client) Close() error {
@@ -92,18 +158,39 @@ func (c *client) Publisher(opts ...EventbusOption) Publisher {
 		apply(&defaultOpts)
 	}
 
+	var quarantine Publisher
+	if defaultOpts.quarantineEventbus != "" {
+		// Quarantined events don't match their schemas, so they aren't
+		// validated again, but their data is protected as the others.
+		quarantineOpts := defaultEventbusOptions()
+		WithEventbus(defaultOpts.quarantineNamespace, defaultOpts.quarantineEventbus)(&quarantineOpts)
+		quarantineOpts.keyProvider = defaultOpts.keyProvider
+		quarantineOpts.compression = defaultOpts.compression
+		quarantineOpts.compressMinSize = defaultOpts.compressMinSize
+		quarantineOpts.blobStore = defaultOpts.blobStore
+		quarantineOpts.claimCheckThreshold = defaultOpts.claimCheckThreshold
+		quarantine = c.publisher(quarantineOpts, nil, nil)
+	}
+	return c.publisher(defaultOpts, c.schemaRegistry, quarantine)
+}
+
+func (c *client) publisher(opts eventbusOptions, registry SchemaRegistry, quarantine Publisher) Publisher {
 	// lazy initialize publisher
 	f := func(ctx context.Context, opt *eventbusOptions) error {
-		md, err := c.Controller().Eventbus().Get(ctx, WithEventbus(opt.namespace, opt.eventbusName))
+		md, err := c.Controller().Eventbus().Get(ctx, WithEventbus(opt.namespace, opt.eventbusName),
+			WithEventbusID(NewID(opt.eventbusID)))
 		if err != nil {
 			return err
 		}
 		opt.eventbusID = md.Id
+		opt.eventlogs = make([]uint64, 0, len(md.Logs))
+		for _, l := range md.Logs {
+			opt.eventlogs = append(opt.eventlogs, l.EventlogId)
+		}
 		return nil
 	}
 
-	// TODO(wenfeng) use connection pool
-	return newPublisher(c.conn, f, defaultOpts)
+	return newPublisher(c.connection(), f, opts, registry, quarantine, c.cache)
 }
 
 func (c *client) Subscriber(opts ...SubscriptionOption) Subscriber {
@@ -112,13 +199,34 @@ func (c *client) Subscriber(opts ...SubscriptionOption) Subscriber {
 		apply(&defaultOptions)
 	}
 
-	value, ok := c.subscriberCache.Load(defaultOptions.subscriptionID)
-	if ok {
+	key := defaultOptions.key()
+	if value, ok := c.subscriberCache.Load(key); ok && !value.(*subscribe).closed() {
 		return value.(Subscriber)
 	}
 
-	subscribe := newSubscriber(c.conn, defaultOptions)
+	// A closed subscriber is replaced, so Listen works again after Close,
+	// e.g. to resume a subscription paused by ClosePause.
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	if value, ok := c.subscriberCache.Load(key); ok && !value.(*subscribe).closed() {
+		return value.(Subscriber)
+	}
+	subscribe := c.subscriber(defaultOptions)
+	c.subscriberCache.Store(key, subscribe)
+	return subscribe
+}
+
+// uncachedSubscriber returns a new subscriber of c, which isn't shared with the
+// callers of Subscriber, e.g. to close it on its own.
+func uncachedSubscriber(c Client, opts ...SubscriptionOption) Subscriber {
+	if cc, ok := c.(*client); ok {
+		return cc.subscriber(newSubscriptionOptions(opts...))
+	}
+	// Subscribers of other clients, e.g. FailoverClient, aren't cached.
+	return c.Subscriber(opts...)
+}
 
-	value, _ = c.subscriberCache.LoadOrStore(defaultOptions.subscriptionID, subscribe)
-	return value.(Subscriber)
+// subscriber returns a new subscriber, which isn't cached.
+func (c *client) subscriber(options subscriptionOptions) Subscriber {
+	return newSubscriber(c.connection(), options, c.schemaRegistry, c.cache)
 }
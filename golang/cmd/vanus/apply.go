@@ -0,0 +1,83 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	// standard libraries.
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	// first-party libraries.
+	vanus "github.com/vanus-labs/sdk/golang"
+)
+
+func apply(ctx context.Context, g *globals, args []string) error {
+	fs := g.flagSet("apply")
+	file := fs.String("file", "", "desired state document in YAML or JSON")
+	fs.StringVar(file, "f", "", "shorthand of --file")
+	dryRun := fs.Bool("dry-run", false, "print the plan without applying it")
+	prune := fs.Bool("prune", false, "delete resources of the managed namespaces which are absent from the document")
+	var protected stringsFlag
+	fs.Var(&protected, "protect", "protect kind/namespace/name from pruning, may be repeated")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("usage: vanus apply -f FILE [--dry-run] [--prune] [--protect kind/namespace/name]")
+	}
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+	desired, err := vanus.LoadDesiredState(data)
+	if err != nil {
+		return err
+	}
+	c, err := g.connect()
+	if err != nil {
+		return err
+	}
+	r := vanus.NewReconciler(c.Controller(),
+		vanus.WithDryRun(*dryRun), vanus.WithPrune(*prune), vanus.WithProtected(protected...))
+	plan, err := r.Reconcile(ctx, desired)
+	if plan == nil {
+		return err
+	}
+	if g.output != outputTable && g.output != "" {
+		if perr := g.print(plan, nil); perr != nil {
+			return perr
+		}
+		return err
+	}
+	fmt.Fprint(stdout, plan)
+	if *dryRun && !plan.Empty() {
+		fmt.Fprintln(stdout, "Dry run, nothing is applied.")
+	}
+	return err
+}
+
+// stringsFlag is a repeatable string flag.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return fmt.Sprint([]string(*f))
+}
+
+func (f *stringsFlag) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
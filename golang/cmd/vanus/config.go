@@ -0,0 +1,176 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	// standard libraries.
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	// third-party libraries.
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	envConfig   = "VANUS_CONFIG"
+	envEndpoint = "VANUS_ENDPOINT"
+	envToken    = "VANUS_TOKEN"
+
+	defaultProfile = "default"
+)
+
+// config is the config file of vanus, ~/.vanus/config.yaml by default.
+type config struct {
+	Current  string             `json:"current"`
+	Profiles map[string]profile `json:"profiles"`
+}
+
+type profile struct {
+	Endpoint string `json:"endpoint"`
+	Token    string `json:"token,omitempty"`
+}
+
+func configPath() (string, error) {
+	if p := os.Getenv(envConfig); p != "" {
+		return p, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".vanus", "config.yaml"), nil
+}
+
+func loadConfig() (*config, error) {
+	cfg := &config{Current: defaultProfile, Profiles: map[string]profile{}}
+	p, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", p, err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = map[string]profile{}
+	}
+	return cfg, nil
+}
+
+func (cfg *config) save() error {
+	p, err := configPath()
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return err
+	}
+	// The file contains tokens.
+	return os.WriteFile(p, data, 0o600)
+}
+
+func configList(_ context.Context, g *globals, args []string) error {
+	fs := g.flagSet("config list")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Don't print tokens.
+	redacted := &config{Current: cfg.Current, Profiles: make(map[string]profile, len(cfg.Profiles))}
+	t := &table{header: []string{"CURRENT", "NAME", "ENDPOINT"}}
+	for _, name := range names {
+		current := ""
+		if name == cfg.Current {
+			current = "*"
+		}
+		t.append(current, name, cfg.Profiles[name].Endpoint)
+		redacted.Profiles[name] = profile{Endpoint: cfg.Profiles[name].Endpoint}
+	}
+	return g.print(redacted, t)
+}
+
+func configSetProfile(_ context.Context, g *globals, args []string) error {
+	fs := g.flagSet("config set-profile NAME")
+	use := fs.Bool("use", false, "use the profile as the current profile")
+	names, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(names) != 1 {
+		return errors.New("usage: vanus config set-profile NAME --endpoint ENDPOINT [--token TOKEN] [--use]")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	p := cfg.Profiles[names[0]]
+	if g.endpoint != "" {
+		p.Endpoint = g.endpoint
+	}
+	if g.token != "" {
+		p.Token = g.token
+	}
+	if p.Endpoint == "" {
+		return errors.New("--endpoint is required")
+	}
+	cfg.Profiles[names[0]] = p
+	if *use || len(cfg.Profiles) == 1 {
+		cfg.Current = names[0]
+	}
+	return cfg.save()
+}
+
+func configUse(_ context.Context, g *globals, args []string) error {
+	fs := g.flagSet("config use NAME")
+	names, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(names) != 1 {
+		return errors.New("usage: vanus config use NAME")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if _, ok := cfg.Profiles[names[0]]; !ok {
+		return fmt.Errorf("profile %q is not found", names[0])
+	}
+	cfg.Current = names[0]
+	return cfg.save()
+}
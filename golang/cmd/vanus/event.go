@@ -0,0 +1,351 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	// standard libraries.
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	// third-party libraries.
+	v2 "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"sigs.k8s.io/yaml"
+
+	// first-party libraries.
+	vanus "github.com/vanus-labs/sdk/golang"
+)
+
+const (
+	defaultSource    = "vanus-cli"
+	defaultBatchSize = 16
+	maxLineSize      = 16 * 1024 * 1024
+)
+
+func publish(ctx context.Context, g *globals, args []string) error {
+	ref := &eventbusRef{}
+	fs := g.flagSet("publish")
+	ref.register(fs)
+	id := fs.String("id", "", "id of the event (default is a random UUID)")
+	source := fs.String("source", defaultSource, "source of the event")
+	eventType := fs.String("type", "", "type of the event")
+	subject := fs.String("subject", "", "subject of the event")
+	contentType := fs.String("content-type", vanus.ContentTypeJSON, "datacontenttype of the event")
+	data := fs.String("data", "", "data of the event")
+	file := fs.String("file", "", `file of structured events in JSON lines, "-" means stdin`)
+	batch := fs.Int("batch", defaultBatchSize, "number of events per request when publishing from a file")
+	names, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err = ref.positional(names); err != nil {
+		return err
+	}
+	opts, err := ref.options()
+	if err != nil {
+		return err
+	}
+	c, err := g.connect()
+	if err != nil {
+		return err
+	}
+	p := c.Publisher(opts...)
+	defer p.Close()
+
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	if *file == "" && !set["type"] && !set["data"] {
+		// Neither the event nor file, read events from stdin.
+		*file = "-"
+	}
+	if *file == "" {
+		e := v2.NewEvent()
+		if *id == "" {
+			*id = uuid.NewString()
+		}
+		e.SetID(*id)
+		e.SetSource(*source)
+		e.SetType(*eventType)
+		if *subject != "" {
+			e.SetSubject(*subject)
+		}
+		e.SetDataContentType(*contentType)
+		e.DataEncoded = []byte(*data)
+		if err = e.Validate(); err != nil {
+			return err
+		}
+		if err = p.Publish(ctx, &e); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "event %s published\n", e.ID())
+		return nil
+	}
+
+	var r io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	n, err := publishLines(ctx, p, r, *batch)
+	fmt.Fprintf(stdout, "%d events published\n", n)
+	return err
+}
+
+// publishLines publishes the structured events of r in JSON lines by batches.
+func publishLines(ctx context.Context, p vanus.Publisher, r io.Reader, batch int) (int, error) {
+	if batch <= 0 {
+		batch = defaultBatchSize
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	var (
+		events []*v2.Event
+		total  int
+		line   int
+	)
+	flush := func() error {
+		if len(events) == 0 {
+			return nil
+		}
+		if err := p.Publish(ctx, events...); err != nil {
+			return err
+		}
+		total += len(events)
+		events = events[:0]
+		return nil
+	}
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		e := v2.NewEvent()
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return total, fmt.Errorf("line %d: %w", line, err)
+		}
+		if e.ID() == "" {
+			e.SetID(uuid.NewString())
+		}
+		events = append(events, &e)
+		if len(events) >= batch {
+			if err := flush(); err != nil {
+				return total, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return total, err
+	}
+	return total, flush()
+}
+
+func tail(ctx context.Context, g *globals, args []string) error {
+	ref := &subscriptionRef{}
+	fs := g.flagSet("tail SUBSCRIPTION_ID")
+	ref.register(fs)
+	max := fs.Int("max", 0, "exit after receiving the number of events, 0 means unlimited")
+	noAck := fs.Bool("no-ack", false, "nack the events, so they will be redelivered")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	opt, _, err := ref.options("vanus tail SUBSCRIPTION_ID", args)
+	if err != nil {
+		return err
+	}
+	c, err := g.connect()
+	if err != nil {
+		return err
+	}
+	s := c.Subscriber(opt, vanus.WithActiveMode(true), vanus.WithParallelism(1))
+
+	var (
+		mu       sync.Mutex
+		received int
+		done     = make(chan struct{})
+		doneOnce sync.Once
+	)
+	errC := make(chan error, 1)
+	go func() {
+		errC <- s.Listen(func(_ context.Context, msgs ...vanus.Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			for _, msg := range msgs {
+				if *max > 0 && received >= *max {
+					msg.Failed(errors.New("tail exited"))
+					continue
+				}
+				if err := g.printEvent(msg.GetEvent()); err != nil {
+					msg.Failed(err)
+					continue
+				}
+				if *noAck {
+					msg.Failed(errors.New("nack by tail"))
+				} else {
+					msg.Success()
+				}
+				received++
+				if *max > 0 && received >= *max {
+					doneOnce.Do(func() { close(done) })
+				}
+			}
+			return nil
+		})
+	}()
+
+	select {
+	case <-ctx.Done():
+	case <-done:
+	case err = <-errC:
+	}
+	_ = s.Close()
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+func (g *globals) printEvent(e *v2.Event) error {
+	switch g.output {
+	case outputTable, "":
+		fmt.Fprintf(stdout, "%s  %s  %s  %s\n", e.Time().Format(time.RFC3339), e.ID(), e.Type(), e.Data())
+		return nil
+	case outputJSON:
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, string(data))
+		return nil
+	case outputYAML:
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if data, err = yaml.JSONToYAML(data); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "---\n%s", data)
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q, use table, json or yaml", g.output)
+	}
+}
+
+func lookupOffset(ctx context.Context, g *globals, args []string) error {
+	ref := &eventbusRef{}
+	fs := g.flagSet("lookup-offset")
+	ref.register(fs)
+	at := fs.String("time", "", "RFC3339 time to look up (default is now)")
+	names, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err = ref.positional(names); err != nil {
+		return err
+	}
+	opts, err := ref.options()
+	if err != nil {
+		return err
+	}
+	ts := time.Now()
+	if *at != "" {
+		if ts, err = time.Parse(time.RFC3339, *at); err != nil {
+			return fmt.Errorf("invalid time %q: %w", *at, err)
+		}
+	}
+	c, err := g.connect()
+	if err != nil {
+		return err
+	}
+	res, err := c.Controller().Eventbus().LookupOffset(ctx, ts, opts...)
+	if err != nil {
+		return err
+	}
+
+	logs := make([]uint64, 0, len(res.Offsets))
+	for log := range res.Offsets {
+		logs = append(logs, log)
+	}
+	sort.Slice(logs, func(i, j int) bool { return logs[i] < logs[j] })
+	t := &table{header: []string{"EVENTLOG_ID", "OFFSET"}}
+	for _, log := range logs {
+		t.append(vanus.NewID(log).Hex(), res.Offsets[log])
+	}
+	return g.print(res, t)
+}
+
+func getEvent(ctx context.Context, g *globals, args []string) error {
+	ref := &eventbusRef{}
+	fs := g.flagSet("get-event")
+	ref.register(fs)
+	eventID := fs.String("id", "", "id of the event")
+	offset := fs.Int64("offset", 0, "offset of the first event, if --id is not set")
+	number := fs.Int("number", 1, "number of events from --offset")
+	names, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err = ref.positional(names); err != nil {
+		return err
+	}
+	opts, err := ref.options()
+	if err != nil {
+		return err
+	}
+	c, err := g.connect()
+	if err != nil {
+		return err
+	}
+	eb, err := c.Controller().Eventbus().Get(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	opt := vanus.WithBatchEvents(vanus.NewID(eb.Id), *offset, int32(*number))
+	if *eventID != "" {
+		opt = vanus.WithEventID(vanus.NewID(eb.Id), *eventID)
+	}
+	res, err := c.Controller().Event().Get(ctx, opt)
+	if err != nil {
+		return err
+	}
+	for _, raw := range res.Events {
+		e := v2.NewEvent()
+		if err = json.Unmarshal(raw.Value, &e); err != nil {
+			// Not a structured event, print it as is.
+			fmt.Fprintln(stdout, string(raw.Value))
+			continue
+		}
+		if err = g.printEvent(&e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
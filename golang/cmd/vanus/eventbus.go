@@ -0,0 +1,207 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	// standard libraries.
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	// first-party libraries.
+	vanus "github.com/vanus-labs/sdk/golang"
+)
+
+const defaultNamespace = "default"
+
+// eventbusRef addresses an eventbus by --namespace and NAME, or by --eventbus-id.
+type eventbusRef struct {
+	namespace string
+	name      string
+	id        vanus.ID
+}
+
+func (r *eventbusRef) register(fs *flag.FlagSet) {
+	fs.StringVar(&r.namespace, "namespace", defaultNamespace, "namespace of the eventbus")
+	fs.StringVar(&r.name, "eventbus", "", "name of the eventbus")
+	fs.Var(&r.id, "eventbus-id", "id of the eventbus in hex, instead of --namespace and --eventbus")
+}
+
+func (r *eventbusRef) options() ([]vanus.EventbusOption, error) {
+	if r.id != 0 {
+		return []vanus.EventbusOption{vanus.WithEventbusID(r.id)}, nil
+	}
+	if r.name == "" {
+		return nil, errors.New("eventbus is required, set it by NAME, --eventbus or --eventbus-id")
+	}
+	return []vanus.EventbusOption{vanus.WithEventbus(r.namespace, r.name)}, nil
+}
+
+// positional takes the eventbus name from the positional arguments.
+func (r *eventbusRef) positional(names []string) error {
+	switch len(names) {
+	case 0:
+		return nil
+	case 1:
+		r.name = names[0]
+		return nil
+	default:
+		return fmt.Errorf("too many arguments: %v", names)
+	}
+}
+
+func eventbusTable(ebs ...*vanus.EventbusInfo) *table {
+	t := &table{header: []string{"ID", "NAME", "NAMESPACE_ID", "LOGS", "DESCRIPTION", "CREATED"}}
+	for _, eb := range ebs {
+		t.append(eb.ID.Hex(), eb.Name, eb.NamespaceID.Hex(), eb.LogNumber,
+			eb.Description, formatMillis(eb.CreatedAt.UnixMilli()))
+	}
+	return t
+}
+
+func eventbusList(ctx context.Context, g *globals, args []string) error {
+	lf := &listFlags{}
+	fs := g.flagSet("eventbus list")
+	lf.register(fs, false)
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	c, err := g.connect()
+	if err != nil {
+		return err
+	}
+	ebs, err := c.Controller().Eventbus().ListInfo(ctx, lf.options()...)
+	if err != nil {
+		return err
+	}
+	return g.print(ebs, eventbusTable(ebs...))
+}
+
+func eventbusGet(ctx context.Context, g *globals, args []string) error {
+	ref := &eventbusRef{}
+	fs := g.flagSet("eventbus get NAME")
+	ref.register(fs)
+	names, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err = ref.positional(names); err != nil {
+		return err
+	}
+	opts, err := ref.options()
+	if err != nil {
+		return err
+	}
+	c, err := g.connect()
+	if err != nil {
+		return err
+	}
+	eb, err := c.Controller().Eventbus().GetInfo(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	return g.print(eb, eventbusTable(eb))
+}
+
+func eventbusStats(ctx context.Context, g *globals, args []string) error {
+	ref := &eventbusRef{}
+	fs := g.flagSet("eventbus stats NAME")
+	ref.register(fs)
+	names, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err = ref.positional(names); err != nil {
+		return err
+	}
+	opts, err := ref.options()
+	if err != nil {
+		return err
+	}
+	c, err := g.connect()
+	if err != nil {
+		return err
+	}
+	stats, err := c.Controller().Eventbus().Stats(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	t := &table{header: []string{"EVENTLOG", "EARLIEST", "LATEST", "EVENTS", "BYTES", "SEGMENTS", "FIRST_EVENT", "LAST_EVENT"}}
+	for _, l := range stats.Eventlogs {
+		t.append(l.ID.Hex(), l.EarliestOffset, l.LatestOffset, l.Events, l.Bytes, l.Segments,
+			formatTime(l.FirstAt), formatTime(l.LastAt))
+	}
+	t.append("TOTAL", "", "", stats.Events, stats.Bytes, stats.Segments, formatTime(stats.FirstAt), formatTime(stats.LastAt))
+	return g.print(stats, t)
+}
+
+func eventbusCreate(ctx context.Context, g *globals, args []string) error {
+	ref := &eventbusRef{}
+	fs := g.flagSet("eventbus create NAME")
+	ref.register(fs)
+	logNumber := fs.Int("log-number", 0, "number of eventlogs (default 1)")
+	description := fs.String("description", "", "description of the eventbus")
+	names, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err = ref.positional(names); err != nil {
+		return err
+	}
+	if ref.name == "" {
+		return errors.New("usage: vanus eventbus create NAME [--namespace NAMESPACE] [--log-number N] [--description TEXT]")
+	}
+	c, err := g.connect()
+	if err != nil {
+		return err
+	}
+	opts := []vanus.EventbusOption{vanus.WithEventbus(ref.namespace, ref.name), vanus.WithLogNumber(*logNumber)}
+	if *description != "" {
+		opts = append(opts, vanus.WithDescription(*description))
+	}
+	pb, err := c.Controller().Eventbus().Create(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	eb := vanus.NewEventbusInfo(pb)
+	return g.print(eb, eventbusTable(eb))
+}
+
+func eventbusDelete(ctx context.Context, g *globals, args []string) error {
+	ref := &eventbusRef{}
+	fs := g.flagSet("eventbus delete NAME")
+	ref.register(fs)
+	names, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err = ref.positional(names); err != nil {
+		return err
+	}
+	opts, err := ref.options()
+	if err != nil {
+		return err
+	}
+	c, err := g.connect()
+	if err != nil {
+		return err
+	}
+	if err = c.Controller().Eventbus().Delete(ctx, opts...); err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, "eventbus deleted")
+	return nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	// standard libraries.
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	// first-party libraries.
+	vanus "github.com/vanus-labs/sdk/golang"
+)
+
+func exportEventbus(ctx context.Context, g *globals, args []string) error {
+	ref := &eventbusRef{}
+	fs := g.flagSet("export")
+	ref.register(fs)
+	dir := fs.String("dir", "", "directory of the exported files and the manifest")
+	format := fs.String("format", vanus.FormatNDJSON, "format of the exported files")
+	from := fs.String("from", "", "RFC3339 time of the first event to export")
+	to := fs.String("to", "", "RFC3339 time to export events until")
+	startOffset := fs.Int64("start-offset", 0, "offset of every eventlog to export from")
+	endOffset := fs.Int64("end-offset", -1, "offset of every eventlog to export until, -1 means the latest")
+	names, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err = ref.positional(names); err != nil {
+		return err
+	}
+	if *dir == "" {
+		return errors.New("usage: vanus export EVENTBUS --dir DIR [--from TIME] [--to TIME]")
+	}
+	opts, err := ref.options()
+	if err != nil {
+		return err
+	}
+	var fromTime, toTime time.Time
+	if fromTime, err = parseTime("from", *from); err != nil {
+		return err
+	}
+	if toTime, err = parseTime("to", *to); err != nil {
+		return err
+	}
+	c, err := g.connect()
+	if err != nil {
+		return err
+	}
+	m, err := vanus.Export(ctx, c, *dir, opts[0], vanus.WithExportFormat(*format),
+		vanus.WithExportTimeRange(fromTime, toTime), vanus.WithExportOffsetRange(*startOffset, *endOffset))
+	if err != nil {
+		return err
+	}
+	t := &table{header: []string{"EVENTLOG", "START_OFFSET", "END_OFFSET", "EVENTS", "FILE"}}
+	for _, el := range m.Eventlogs {
+		t.append(el.ID.Hex(), el.StartOffset, el.EndOffset, el.Events, el.File)
+	}
+	t.append("TOTAL", "", "", m.Events, vanus.ManifestFile)
+	return g.print(m, t)
+}
+
+func importEventbus(ctx context.Context, g *globals, args []string) error {
+	ref := &eventbusRef{}
+	fs := g.flagSet("import")
+	ref.register(fs)
+	dir := fs.String("dir", "", "directory of an export")
+	batch := fs.Int("batch", defaultBatchSize, "number of events per request")
+	limit := fs.Float64("rate", 0, "import at most N events per second, 0 means unlimited")
+	burst := fs.Int("burst", defaultBatchSize, "burst of --rate")
+	restart := fs.Bool("restart", false, "import all events again, instead of resuming an interrupted import")
+	names, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err = ref.positional(names); err != nil {
+		return err
+	}
+	if *dir == "" {
+		return errors.New("usage: vanus import --dir DIR [EVENTBUS] (default is the exported eventbus)")
+	}
+	opts := []vanus.ImportOption{
+		vanus.WithImportBatchSize(*batch),
+		vanus.WithImportRateLimit(*limit, *burst),
+	}
+	if ref.id != 0 {
+		return errors.New("import addresses the eventbus by name, use NAME or --eventbus")
+	}
+	if ref.name != "" {
+		opts = append(opts, vanus.WithImportEventbus(ref.namespace, ref.name))
+	}
+	if *restart {
+		opts = append(opts, vanus.WithImportRestart())
+	}
+	c, err := g.connect()
+	if err != nil {
+		return err
+	}
+	stats, err := vanus.Import(ctx, c, *dir, opts...)
+	if stats != nil {
+		fmt.Fprintf(stdout, "%d events imported, %d skipped as imported before\n", stats.Imported, stats.Skipped)
+	}
+	return err
+}
+
+func parseTime(flag, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --%s %q: %w", flag, value, err)
+	}
+	return t, nil
+}
@@ -0,0 +1,224 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command vanus manages and uses Vanus clusters by the Go SDK.
+package main
+
+import (
+	// standard libraries.
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	// first-party libraries.
+	vanus "github.com/vanus-labs/sdk/golang"
+)
+
+const usage = `vanus is the command line client of Vanus.
+
+Usage:
+  vanus <command> [subcommand] [flags]
+
+Commands:
+  namespace      list|get|create|delete namespaces
+  eventbus       list|get|create|delete|stats eventbuses
+  subscription   list|get|create|delete|pause|resume|lag|watch subscriptions
+  publish        publish events from flags, a file or stdin (JSON lines)
+  tail           consume events of a subscription and print them
+  lookup-offset  look up the offsets of an eventbus at a time
+  get-event      get events of an eventbus by id or offset
+  apply          converge resources to a desired state document
+  mirror         copy the events of a subscription to an eventbus of any cluster
+  export         export the events of an eventbus to files with a manifest
+  import         publish the events of an export to an eventbus
+  config         manage profiles of endpoints and tokens
+
+Global flags, accepted by every command:
+  --profile string    profile in the config file (default is the current profile)
+  --endpoint string   endpoints of the Vanus proxy, comma-separated, overrides the profile
+  --token string      token of the Vanus proxy, overrides the profile
+  -o, --output string output format: table, json or yaml (default "table")
+
+The endpoint and token are also read from VANUS_ENDPOINT and VANUS_TOKEN.
+`
+
+// command is a leaf command, which parses its flags and runs.
+type command func(ctx context.Context, g *globals, args []string) error
+
+var commands = map[string]map[string]command{
+	"namespace": {
+		"list":   namespaceList,
+		"get":    namespaceGet,
+		"create": namespaceCreate,
+		"delete": namespaceDelete,
+	},
+	"eventbus": {
+		"list":   eventbusList,
+		"get":    eventbusGet,
+		"create": eventbusCreate,
+		"delete": eventbusDelete,
+		"stats":  eventbusStats,
+	},
+	"subscription": {
+		"list":   subscriptionList,
+		"get":    subscriptionGet,
+		"create": subscriptionCreate,
+		"delete": subscriptionDelete,
+		"pause":  subscriptionPause,
+		"resume": subscriptionResume,
+		"lag":    subscriptionLag,
+		"watch":  subscriptionWatch,
+	},
+	"config": {
+		"list":        configList,
+		"set-profile": configSetProfile,
+		"use":         configUse,
+	},
+}
+
+var leafCommands = map[string]command{
+	"publish":       publish,
+	"tail":          tail,
+	"lookup-offset": lookupOffset,
+	"get-event":     getEvent,
+	"apply":         apply,
+	"mirror":        mirror,
+	"export":        exportEventbus,
+	"import":        importEventbus,
+}
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if err := run(ctx, os.Args[1:]); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		fmt.Print(usage)
+		return nil
+	}
+	g := &globals{}
+	if cmd, ok := leafCommands[args[0]]; ok {
+		return cmd(ctx, g, args[1:])
+	}
+	subs, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %q, see `vanus help`", args[0])
+	}
+	if len(args) < 2 {
+		return fmt.Errorf("%s requires a subcommand: %s", args[0], subcommandNames(subs))
+	}
+	cmd, ok := subs[args[1]]
+	if !ok {
+		return fmt.Errorf("unknown subcommand %q of %s: %s", args[1], args[0], subcommandNames(subs))
+	}
+	return cmd(ctx, g, args[2:])
+}
+
+func subcommandNames(subs map[string]command) string {
+	names := make([]string, 0, len(subs))
+	for name := range subs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, "|")
+}
+
+// globals are the flags of all commands.
+type globals struct {
+	profile  string
+	endpoint string
+	token    string
+	output   string
+}
+
+func (g *globals) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("vanus "+name, flag.ContinueOnError)
+	fs.StringVar(&g.profile, "profile", "", "profile in the config file")
+	fs.StringVar(&g.endpoint, "endpoint", "", "endpoints of the Vanus proxy, comma-separated")
+	fs.StringVar(&g.token, "token", "", "token of the Vanus proxy")
+	fs.StringVar(&g.output, "output", outputTable, "output format: table, json or yaml")
+	fs.StringVar(&g.output, "o", outputTable, "shorthand of --output")
+	return fs
+}
+
+// parse parses flags interspersed with positional arguments.
+func parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+func (g *globals) connect() (vanus.Client, error) {
+	p, err := g.resolveProfile()
+	if err != nil {
+		return nil, err
+	}
+	if p.Endpoint == "" {
+		return nil, errors.New("endpoint is required, set it by --endpoint or `vanus config set-profile`")
+	}
+	return vanus.Connect(&vanus.ClientOptions{
+		Endpoint: p.Endpoint,
+		Token:    p.Token,
+	})
+}
+
+func (g *globals) resolveProfile() (profile, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return profile{}, err
+	}
+	name := g.profile
+	if name == "" {
+		name = cfg.Current
+	}
+	p := cfg.Profiles[name]
+	if g.profile != "" && p.Endpoint == "" {
+		return profile{}, fmt.Errorf("profile %q is not found", g.profile)
+	}
+	if v := os.Getenv(envEndpoint); v != "" {
+		p.Endpoint = v
+	}
+	if v := os.Getenv(envToken); v != "" {
+		p.Token = v
+	}
+	if g.endpoint != "" {
+		p.Endpoint = g.endpoint
+	}
+	if g.token != "" {
+		p.Token = g.token
+	}
+	return p, nil
+}
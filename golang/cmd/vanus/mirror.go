@@ -0,0 +1,156 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	// standard libraries.
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	// third-party libraries.
+	v2 "github.com/cloudevents/sdk-go/v2"
+
+	// first-party libraries.
+	vanus "github.com/vanus-labs/sdk/golang"
+)
+
+func mirror(ctx context.Context, g *globals, args []string) error {
+	ref := &subscriptionRef{}
+	fs := g.flagSet("mirror SUBSCRIPTION_ID")
+	ref.register(fs)
+	toNamespace := fs.String("to-namespace", defaultNamespace, "namespace of the target eventbus")
+	toEventbus := fs.String("to-eventbus", "", "name of the target eventbus")
+	toProfile := fs.String("to-profile", "", "profile of the target cluster (default is the source cluster)")
+	toEndpoint := fs.String("to-endpoint", "", "endpoints of the target cluster, overrides --to-profile")
+	toToken := fs.String("to-token", "", "token of the target cluster, overrides --to-profile")
+	eventType := fs.String("type", "", "mirror only events whose type matches the pattern, e.g. 'order.*'")
+	var extensions stringsFlag
+	fs.Var(&extensions, "extension", "set the extension NAME=VALUE on mirrored events, may be repeated")
+	limit := fs.Float64("rate", 0, "mirror at most N events per second, 0 means unlimited")
+	burst := fs.Int("burst", defaultBatchSize, "burst of --rate")
+	interval := fs.Duration("report-interval", 10*time.Second, "interval of reporting progress and lag, 0 disables it")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	usage := "vanus mirror SUBSCRIPTION_ID --to-eventbus NAME"
+	from, _, err := ref.options(usage, args)
+	if err != nil {
+		return err
+	}
+	if *toEventbus == "" {
+		return errors.New("usage: " + usage + " [--to-namespace NAMESPACE] [--to-profile PROFILE]")
+	}
+	if *eventType != "" {
+		if _, err = path.Match(*eventType, ""); err != nil {
+			return fmt.Errorf("invalid --type %q: %w", *eventType, err)
+		}
+	}
+	ext := make(map[string]string, len(extensions))
+	for _, kv := range extensions {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || name == "" {
+			return fmt.Errorf("invalid --extension %q, use NAME=VALUE", kv)
+		}
+		ext[name] = value
+	}
+
+	source, err := g.connect()
+	if err != nil {
+		return err
+	}
+	target := source
+	if *toProfile != "" || *toEndpoint != "" || *toToken != "" {
+		if target, err = connectProfile(*toProfile, *toEndpoint, *toToken); err != nil {
+			return err
+		}
+	}
+
+	opts := []vanus.MirrorOption{vanus.WithMirrorRateLimit(*limit, *burst)}
+	if *eventType != "" {
+		opts = append(opts, vanus.WithMirrorFilter(func(e *v2.Event) bool {
+			matched, _ := path.Match(*eventType, e.Type())
+			return matched
+		}))
+	}
+	if len(ext) != 0 {
+		opts = append(opts, vanus.WithMirrorTransform(func(e *v2.Event) (*v2.Event, error) {
+			for name, value := range ext {
+				e.SetExtension(name, value)
+			}
+			return e, nil
+		}))
+	}
+	m := vanus.NewMirror(source, target, from, vanus.WithEventbus(*toNamespace, *toEventbus), opts...)
+	if *interval > 0 {
+		go reportMirror(ctx, m, *interval)
+	}
+	err = m.Run(ctx)
+	stats := m.Stats()
+	fmt.Fprintf(stdout, "%d events mirrored, %d filtered, %d failed\n", stats.Mirrored, stats.Filtered, stats.Failed)
+	return err
+}
+
+func reportMirror(ctx context.Context, m *vanus.Mirror, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		stats := m.Stats()
+		lag := "unknown"
+		if l, err := m.Lag(ctx); err == nil {
+			lag = fmt.Sprint(l.Total)
+		}
+		fmt.Fprintf(stdout, "%s  mirrored=%d filtered=%d failed=%d lag=%s\n", time.Now().Format(time.RFC3339),
+			stats.Mirrored, stats.Filtered, stats.Failed, lag)
+	}
+}
+
+// connectProfile connects to the profile, overridden by endpoint and token,
+// ignoring the global flags and environment variables.
+func connectProfile(name, endpoint, token string) (vanus.Client, error) {
+	var p profile
+	if name != "" {
+		cfg, err := loadConfig()
+		if err != nil {
+			return nil, err
+		}
+		var ok bool
+		if p, ok = cfg.Profiles[name]; !ok {
+			return nil, fmt.Errorf("profile %q is not found", name)
+		}
+	}
+	if endpoint != "" {
+		p.Endpoint = endpoint
+	}
+	if token != "" {
+		p.Token = token
+	}
+	if p.Endpoint == "" {
+		return nil, errors.New("endpoint of the target is required, set it by --to-profile or --to-endpoint")
+	}
+	return vanus.Connect(&vanus.ClientOptions{
+		Endpoint: p.Endpoint,
+		Token:    p.Token,
+	})
+}
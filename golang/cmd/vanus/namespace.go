@@ -0,0 +1,111 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	// standard libraries.
+	"context"
+	"errors"
+	"fmt"
+
+	// first-party libraries.
+	vanus "github.com/vanus-labs/sdk/golang"
+	metapb "github.com/vanus-labs/vanus/api/meta"
+)
+
+func namespaceTable(nss ...*metapb.Namespace) *table {
+	t := &table{header: []string{"ID", "NAME", "DESCRIPTION", "CREATED"}}
+	for _, ns := range nss {
+		t.append(vanus.NewID(ns.Id).Hex(), ns.Name, ns.Description, formatMillis(ns.CreatedAt))
+	}
+	return t
+}
+
+func namespaceList(ctx context.Context, g *globals, args []string) error {
+	fs := g.flagSet("namespace list")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	c, err := g.connect()
+	if err != nil {
+		return err
+	}
+	nss, err := c.Controller().Namespace().List(ctx)
+	if err != nil {
+		return err
+	}
+	return g.print(nss, namespaceTable(nss...))
+}
+
+func namespaceGet(ctx context.Context, g *globals, args []string) error {
+	fs := g.flagSet("namespace get NAME")
+	names, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(names) != 1 {
+		return errors.New("usage: vanus namespace get NAME")
+	}
+	c, err := g.connect()
+	if err != nil {
+		return err
+	}
+	ns, err := c.Controller().Namespace().Get(ctx, names[0])
+	if err != nil {
+		return err
+	}
+	return g.print(ns, namespaceTable(ns))
+}
+
+func namespaceCreate(ctx context.Context, g *globals, args []string) error {
+	fs := g.flagSet("namespace create NAME")
+	description := fs.String("description", "", "description of the namespace")
+	names, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(names) != 1 {
+		return errors.New("usage: vanus namespace create NAME [--description TEXT]")
+	}
+	c, err := g.connect()
+	if err != nil {
+		return err
+	}
+	ns, err := c.Controller().Namespace().Create(ctx, names[0], *description)
+	if err != nil {
+		return err
+	}
+	return g.print(ns, namespaceTable(ns))
+}
+
+func namespaceDelete(ctx context.Context, g *globals, args []string) error {
+	fs := g.flagSet("namespace delete NAME")
+	names, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(names) != 1 {
+		return errors.New("usage: vanus namespace delete NAME")
+	}
+	c, err := g.connect()
+	if err != nil {
+		return err
+	}
+	if err = c.Controller().Namespace().Delete(ctx, names[0]); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "namespace %s deleted\n", names[0])
+	return nil
+}
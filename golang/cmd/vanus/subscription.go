@@ -0,0 +1,341 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	// standard libraries.
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	// third-party libraries.
+	"google.golang.org/protobuf/encoding/protojson"
+	"sigs.k8s.io/yaml"
+
+	// first-party libraries.
+	vanus "github.com/vanus-labs/sdk/golang"
+	ctrlpb "github.com/vanus-labs/vanus/api/controller"
+	metapb "github.com/vanus-labs/vanus/api/meta"
+)
+
+func subscriptionTable(subs ...*metapb.Subscription) *table {
+	t := &table{header: []string{"ID", "NAME", "EVENTBUS_ID", "SINK", "PROTOCOL", "STATE", "CREATED"}}
+	for _, sub := range subs {
+		t.append(vanus.NewID(sub.Id).Hex(), sub.Name, vanus.NewID(sub.EventbusId).Hex(), sub.Sink,
+			sub.Protocol, vanus.StateOf(sub), formatMillis(sub.CreatedAt))
+	}
+	return t
+}
+
+// subscriptionRef addresses a subscription by the only positional argument as
+// its id, or by --name and --namespace.
+type subscriptionRef struct {
+	namespace string
+	name      string
+}
+
+func (r *subscriptionRef) register(fs *flag.FlagSet) {
+	fs.StringVar(&r.namespace, "namespace", defaultNamespace, "namespace of the subscription of --name")
+	fs.StringVar(&r.name, "name", "", "name of the subscription, instead of its id")
+}
+
+// options returns the options addressing the subscription, and its id or name.
+func (r *subscriptionRef) options(usage string, args []string) (vanus.SubscriptionOption, string, error) {
+	if r.name != "" && len(args) == 0 {
+		return vanus.WithSubscriptionName(r.namespace, r.name), r.name, nil
+	}
+	if len(args) != 1 || r.name != "" {
+		return nil, "", errors.New("usage: " + usage + " | --name NAME [--namespace NAMESPACE]")
+	}
+	id, err := vanus.ParseID(args[0])
+	if err != nil {
+		return nil, "", err
+	}
+	return vanus.WithSubscriptionID(id), id.Hex(), nil
+}
+
+// listFlags are the filters of list and watch commands.
+type listFlags struct {
+	namespace string
+	eventbus  string
+	prefix    string
+	state     string
+	limit     int
+}
+
+func (f *listFlags) register(fs *flag.FlagSet, subscriptions bool) {
+	fs.StringVar(&f.namespace, "namespace", "", "list resources of the namespace")
+	fs.StringVar(&f.prefix, "prefix", "", "list resources whose names start with the prefix")
+	fs.IntVar(&f.limit, "limit", 0, "list at most N resources")
+	if subscriptions {
+		fs.StringVar(&f.eventbus, "eventbus", "", "list subscriptions of the eventbus in --namespace")
+		fs.StringVar(&f.state, "state", "", "list subscriptions in the state: running or paused")
+	}
+}
+
+func (f *listFlags) options() []vanus.ListOption {
+	var opts []vanus.ListOption
+	if f.eventbus != "" {
+		namespace := f.namespace
+		if namespace == "" {
+			namespace = defaultNamespace
+		}
+		opts = append(opts, vanus.OfEventbus(namespace, f.eventbus))
+	} else if f.namespace != "" {
+		opts = append(opts, vanus.InNamespace(f.namespace))
+	}
+	if f.prefix != "" {
+		opts = append(opts, vanus.WithNamePrefix(f.prefix))
+	}
+	if f.state != "" {
+		opts = append(opts, vanus.WithState(vanus.SubscriptionState(f.state)))
+	}
+	return append(opts, vanus.WithMaxResults(f.limit))
+}
+
+func subscriptionList(ctx context.Context, g *globals, args []string) error {
+	lf := &listFlags{}
+	fs := g.flagSet("subscription list")
+	lf.register(fs, true)
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	c, err := g.connect()
+	if err != nil {
+		return err
+	}
+	subs, err := c.Controller().Subscription().List(ctx, lf.options()...)
+	if err != nil {
+		return err
+	}
+	return g.print(subs, subscriptionTable(subs...))
+}
+
+func subscriptionWatch(ctx context.Context, g *globals, args []string) error {
+	lf := &listFlags{}
+	fs := g.flagSet("subscription watch")
+	lf.register(fs, true)
+	interval := fs.Duration("interval", 5*time.Second, "interval of polling the controller")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	c, err := g.connect()
+	if err != nil {
+		return err
+	}
+	changes, err := c.Controller().Subscription().Watch(ctx, append(lf.options(), vanus.WithWatchInterval(*interval))...)
+	if err != nil {
+		return err
+	}
+	for change := range changes {
+		if change.Err != nil {
+			return change.Err
+		}
+		if g.output == outputTable || g.output == "" {
+			sub := change.Subscription
+			fmt.Fprintf(stdout, "%s\t%s\t%s\t%s\n", change.Type, vanus.NewID(sub.Id).Hex(), sub.Name, vanus.StateOf(sub))
+			continue
+		}
+		if err = g.print(change, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func subscriptionGet(ctx context.Context, g *globals, args []string) error {
+	ref := &subscriptionRef{}
+	fs := g.flagSet("subscription get ID")
+	ref.register(fs)
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	opt, _, err := ref.options("vanus subscription get ID", args)
+	if err != nil {
+		return err
+	}
+	c, err := g.connect()
+	if err != nil {
+		return err
+	}
+	sub, err := c.Controller().Subscription().Get(ctx, opt)
+	if err != nil {
+		return err
+	}
+	return g.print(sub, subscriptionTable(sub))
+}
+
+func subscriptionLag(ctx context.Context, g *globals, args []string) error {
+	ref := &subscriptionRef{}
+	fs := g.flagSet("subscription lag ID")
+	ref.register(fs)
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	opt, _, err := ref.options("vanus subscription lag ID", args)
+	if err != nil {
+		return err
+	}
+	c, err := g.connect()
+	if err != nil {
+		return err
+	}
+	lag, err := c.Controller().Subscription().Lag(ctx, opt)
+	if err != nil {
+		return err
+	}
+	t := &table{header: []string{"EVENTLOG", "COMMITTED", "LATEST", "LAG"}}
+	for _, l := range lag.Eventlogs {
+		t.append(l.EventlogID.Hex(), l.Committed, l.Latest, l.Lag)
+	}
+	t.append("TOTAL", "", "", lag.Total)
+	return g.print(lag, t)
+}
+
+func subscriptionCreate(ctx context.Context, g *globals, args []string) error {
+	ref := &eventbusRef{}
+	fs := g.flagSet("subscription create NAME")
+	ref.register(fs)
+	file := fs.String("file", "", "JSON or YAML file of the SubscriptionRequest, flags override it")
+	sink := fs.String("sink", "", "URL of the sink")
+	protocol := fs.String("protocol", "", "protocol of the sink: http, grpc, aws-lambda or gcloud-functions")
+	description := fs.String("description", "", "description of the subscription")
+	filters := fs.String("filters", "", `filters in JSON, e.g. '[{"exact": {"type": "order.created"}}]'`)
+	ordered := fs.Bool("ordered", false, "deliver events in order")
+	paused := fs.Bool("paused", false, "create the subscription in paused state")
+	names, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	req := &ctrlpb.SubscriptionRequest{}
+	if *file != "" {
+		data, err := os.ReadFile(*file)
+		if err != nil {
+			return err
+		}
+		if data, err = yaml.YAMLToJSON(data); err != nil {
+			return err
+		}
+		if err = protojson.Unmarshal(data, req); err != nil {
+			return fmt.Errorf("invalid subscription file %s: %w", *file, err)
+		}
+	}
+	if len(names) > 1 {
+		return fmt.Errorf("too many arguments: %v", names)
+	}
+	if len(names) == 1 {
+		req.Name = names[0]
+	}
+	if *sink != "" {
+		req.Sink = *sink
+	}
+	if *protocol != "" {
+		p, ok := metapb.Protocol_value[strings.ToUpper(strings.ReplaceAll(*protocol, "-", "_"))]
+		if !ok {
+			return fmt.Errorf("unknown protocol %q", *protocol)
+		}
+		req.Protocol = metapb.Protocol(p)
+	}
+	if *description != "" {
+		req.Description = *description
+	}
+	if *filters != "" {
+		wrapped := `{"filters": ` + *filters + `}`
+		tmp := &ctrlpb.SubscriptionRequest{}
+		if err = protojson.Unmarshal([]byte(wrapped), tmp); err != nil {
+			return fmt.Errorf("invalid filters: %w", err)
+		}
+		req.Filters = tmp.Filters
+	}
+	if *ordered {
+		if req.Config == nil {
+			req.Config = &metapb.SubscriptionConfig{}
+		}
+		req.Config.OrderedEvent = true
+	}
+	if *paused {
+		req.Disable = true
+	}
+	if req.Name == "" || req.Sink == "" {
+		return errors.New("usage: vanus subscription create NAME --eventbus EVENTBUS --sink URL [flags]")
+	}
+
+	c, err := g.connect()
+	if err != nil {
+		return err
+	}
+	if req.EventbusId == 0 || ref.name != "" || ref.id != 0 {
+		opts, err := ref.options()
+		if err != nil {
+			return err
+		}
+		eb, err := c.Controller().Eventbus().Get(ctx, opts...)
+		if err != nil {
+			return err
+		}
+		req.EventbusId = eb.Id
+		req.NamespaceId = eb.NamespaceId
+	}
+	sub, err := c.Controller().Subscription().Create(ctx, req)
+	if err != nil {
+		return err
+	}
+	return g.print(sub, subscriptionTable(sub))
+}
+
+func subscriptionDelete(ctx context.Context, g *globals, args []string) error {
+	return subscriptionAction(ctx, g, args, "delete", "deleted", vanus.Subscription.Delete)
+}
+
+func subscriptionPause(ctx context.Context, g *globals, args []string) error {
+	return subscriptionAction(ctx, g, args, "pause", "paused", vanus.Subscription.Pause)
+}
+
+func subscriptionResume(ctx context.Context, g *globals, args []string) error {
+	return subscriptionAction(ctx, g, args, "resume", "resumed", vanus.Subscription.Resume)
+}
+
+func subscriptionAction(ctx context.Context, g *globals, args []string, verb, done string,
+	action func(vanus.Subscription, context.Context, ...vanus.SubscriptionOption) error,
+) error {
+	usage := "vanus subscription " + verb + " ID"
+	ref := &subscriptionRef{}
+	fs := g.flagSet("subscription " + verb + " ID")
+	ref.register(fs)
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	opt, label, err := ref.options(usage, args)
+	if err != nil {
+		return err
+	}
+	c, err := g.connect()
+	if err != nil {
+		return err
+	}
+	if err = action(c.Controller().Subscription(), ctx, opt); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "subscription %s %s\n", label, done)
+	return nil
+}
@@ -0,0 +1,223 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vanus
+
+import (
+	// standard libraries.
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+	"sync"
+
+	// third-party libraries.
+	"github.com/hamba/avro"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	ContentTypeJSON        = "application/json"
+	ContentTypeAvro        = "application/avro"
+	ContentTypeMessagePack = "application/msgpack"
+
+	// The dataschema must be an absolute URI, so the type URL prefix of Any
+	// carries the scheme.
+	protobufTypeURLPrefix = "https://type.googleapis.com/"
+)
+
+// Codec encodes Go values to event data and decodes them back.
+type Codec interface {
+	// ContentType is the datacontenttype of events encoded by this codec.
+	ContentType() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// SchemaCodec is implemented by codecs whose payloads are described by a schema,
+// the returned URI is used as the dataschema of the encoded event.
+type SchemaCodec interface {
+	Codec
+	DataSchema(v interface{}) (string, error)
+}
+
+// schemaBound is implemented by codecs which are bound to a single schema, they
+// are registered for their content type and dataschema.
+type schemaBound interface {
+	schemaURI() string
+}
+
+var codecs = struct {
+	mu     sync.RWMutex
+	codecs map[string]Codec
+}{
+	codecs: map[string]Codec{},
+}
+
+func init() {
+	RegisterCodec(JSONCodec())
+	RegisterCodec(ProtobufCodec())
+	RegisterCodec(MessagePackCodec())
+}
+
+// RegisterCodec registers a codec for its content type, it replaces the codec
+// which has been registered with the same content type (and dataschema).
+func RegisterCodec(c Codec) {
+	key := codecKey(c.ContentType(), "")
+	if b, ok := c.(schemaBound); ok && b.schemaURI() != "" {
+		key = codecKey(c.ContentType(), b.schemaURI())
+	}
+	codecs.mu.Lock()
+	defer codecs.mu.Unlock()
+	codecs.codecs[key] = c
+}
+
+// LookupCodec returns the codec for the content type, codecs registered for the
+// dataschema take precedence.
+func LookupCodec(contentType, dataSchema string) (Codec, error) {
+	codecs.mu.RLock()
+	defer codecs.mu.RUnlock()
+	if dataSchema != "" {
+		if c, ok := codecs.codecs[codecKey(contentType, dataSchema)]; ok {
+			return c, nil
+		}
+	}
+	if c, ok := codecs.codecs[codecKey(contentType, "")]; ok {
+		return c, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrCodecNotFound, contentType)
+}
+
+func codecKey(contentType, dataSchema string) string {
+	if mt, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = mt
+	}
+	contentType = strings.ToLower(contentType)
+	if dataSchema == "" {
+		return contentType
+	}
+	return contentType + " " + dataSchema
+}
+
+type jsonCodec struct{}
+
+// JSONCodec returns the codec for application/json.
+func JSONCodec() Codec {
+	return jsonCodec{}
+}
+
+func (jsonCodec) ContentType() string {
+	return ContentTypeJSON
+}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type protobufCodec struct{}
+
+// ProtobufCodec returns the codec for application/protobuf, the dataschema of
+// encoded events is the type URL of the message, so the data can be carried as
+// an anypb.Any.
+func ProtobufCodec() SchemaCodec {
+	return protobufCodec{}
+}
+
+func (protobufCodec) ContentType() string {
+	return ContentTypeProtobuf
+}
+
+func (protobufCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T is not a proto.Message", ErrInvalidArguments, v)
+	}
+	return proto.Marshal(m)
+}
+
+func (protobufCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%w: %T is not a proto.Message", ErrInvalidArguments, v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+func (protobufCodec) DataSchema(v interface{}) (string, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return "", fmt.Errorf("%w: %T is not a proto.Message", ErrInvalidArguments, v)
+	}
+	return protobufTypeURLPrefix + string(m.ProtoReflect().Descriptor().FullName()), nil
+}
+
+type msgpackCodec struct{}
+
+// MessagePackCodec returns the codec for application/msgpack.
+func MessagePackCodec() Codec {
+	return msgpackCodec{}
+}
+
+func (msgpackCodec) ContentType() string {
+	return ContentTypeMessagePack
+}
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+type avroCodec struct {
+	uri    string
+	schema avro.Schema
+}
+
+// NewAvroCodec returns the codec for application/avro with the given schema,
+// uri is used as the dataschema of encoded events. Register it with
+// RegisterCodec so subscribers can decode events by their dataschema.
+func NewAvroCodec(uri, schema string) (SchemaCodec, error) {
+	s, err := avro.Parse(schema)
+	if err != nil {
+		return nil, err
+	}
+	return &avroCodec{uri: uri, schema: s}, nil
+}
+
+func (c *avroCodec) ContentType() string {
+	return ContentTypeAvro
+}
+
+func (c *avroCodec) Marshal(v interface{}) ([]byte, error) {
+	return avro.Marshal(c.schema, v)
+}
+
+func (c *avroCodec) Unmarshal(data []byte, v interface{}) error {
+	return avro.Unmarshal(c.schema, data, v)
+}
+
+func (c *avroCodec) DataSchema(_ interface{}) (string, error) {
+	return c.uri, nil
+}
+
+func (c *avroCodec) schemaURI() string {
+	return c.uri
+}
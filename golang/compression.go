@@ -0,0 +1,244 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vanus
+
+import (
+	// standard libraries.
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sync"
+
+	// third-party libraries.
+	v2 "github.com/cloudevents/sdk-go/v2"
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/grpc/encoding"
+	_ "google.golang.org/grpc/encoding/gzip" // register gzip compressor of gRPC.
+)
+
+const (
+	CompressionGzip   = "gzip"
+	CompressionZstd   = "zstd"
+	CompressionSnappy = "snappy"
+
+	// extContentEncoding is the compression algorithm of the event data.
+	extContentEncoding = "contentencoding"
+)
+
+func init() {
+	encoding.RegisterCompressor(zstdGRPCCompressor{})
+	encoding.RegisterCompressor(snappyGRPCCompressor{})
+
+	RegisterPayloadCompressor(gzipCompressor{})
+	RegisterPayloadCompressor(newZstdCompressor())
+	RegisterPayloadCompressor(snappyCompressor{})
+}
+
+type zstdGRPCCompressor struct{}
+
+func (zstdGRPCCompressor) Name() string {
+	return CompressionZstd
+}
+
+func (zstdGRPCCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w)
+}
+
+// zstdDecoders are decoders of gRPC messages, which gRPC never closes. With
+// the concurrency of 1, a decoder decodes synchronously without goroutines, so
+// it's reused instead.
+var zstdDecoders = sync.Pool{
+	New: func() interface{} {
+		d, _ := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+		return d
+	},
+}
+
+func (zstdGRPCCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	d, _ := zstdDecoders.Get().(*zstd.Decoder)
+	if d == nil {
+		return nil, fmt.Errorf("%w: zstd decoder", ErrCompressorNotFound)
+	}
+	if err := d.Reset(r); err != nil {
+		zstdDecoders.Put(d)
+		return nil, err
+	}
+	return &zstdReader{d: d}, nil
+}
+
+// zstdReader returns the decoder to the pool at the end of the message.
+type zstdReader struct {
+	d *zstd.Decoder
+}
+
+func (z *zstdReader) Read(p []byte) (int, error) {
+	if z.d == nil {
+		return 0, io.EOF
+	}
+	n, err := z.d.Read(p)
+	if err == io.EOF {
+		// Drop the reference of the message.
+		_ = z.d.Reset(nil)
+		zstdDecoders.Put(z.d)
+		z.d = nil
+	}
+	return n, err
+}
+
+type snappyGRPCCompressor struct{}
+
+func (snappyGRPCCompressor) Name() string {
+	return CompressionSnappy
+}
+
+func (snappyGRPCCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	return snappy.NewBufferedWriter(w), nil
+}
+
+func (snappyGRPCCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	return snappy.NewReader(r), nil
+}
+
+// PayloadCompressor compresses the data of events.
+type PayloadCompressor interface {
+	// Name is the value of the contentencoding extension of compressed events.
+	Name() string
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+var payloadCompressors sync.Map
+
+// RegisterPayloadCompressor registers a compressor for its name, which is used
+// by publishers and subscribers of this process.
+func RegisterPayloadCompressor(c PayloadCompressor) {
+	payloadCompressors.Store(c.Name(), c)
+}
+
+func lookupPayloadCompressor(name string) (PayloadCompressor, error) {
+	v, ok := payloadCompressors.Load(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrCompressorNotFound, name)
+	}
+	return v.(PayloadCompressor), nil
+}
+
+type gzipCompressor struct{}
+
+func (gzipCompressor) Name() string {
+	return CompressionGzip
+}
+
+func (gzipCompressor) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipCompressor) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+type zstdCompressor struct {
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+}
+
+func newZstdCompressor() *zstdCompressor {
+	// Both of them never fail without options.
+	encoder, _ := zstd.NewWriter(nil)
+	decoder, _ := zstd.NewReader(nil)
+	return &zstdCompressor{encoder: encoder, decoder: decoder}
+}
+
+func (c *zstdCompressor) Name() string {
+	return CompressionZstd
+}
+
+func (c *zstdCompressor) Compress(data []byte) ([]byte, error) {
+	return c.encoder.EncodeAll(data, nil), nil
+}
+
+func (c *zstdCompressor) Decompress(data []byte) ([]byte, error) {
+	return c.decoder.DecodeAll(data, nil)
+}
+
+type snappyCompressor struct{}
+
+func (snappyCompressor) Name() string {
+	return CompressionSnappy
+}
+
+func (snappyCompressor) Compress(data []byte) ([]byte, error) {
+	return snappy.Encode(nil, data), nil
+}
+
+func (snappyCompressor) Decompress(data []byte) ([]byte, error) {
+	return snappy.Decode(nil, data)
+}
+
+// compressEvent returns a copy of e whose data is compressed by c, e is returned
+// if its data is smaller than minSize.
+func compressEvent(e *v2.Event, c PayloadCompressor, minSize int) (*v2.Event, error) {
+	if len(e.Data()) < minSize || len(e.Data()) == 0 {
+		return e, nil
+	}
+	if _, ok := e.Extensions()[extContentEncoding]; ok {
+		// Already compressed by the producer.
+		return e, nil
+	}
+	data, err := c.Compress(e.Data())
+	if err != nil {
+		return nil, err
+	}
+	out := e.Clone()
+	out.DataEncoded = data
+	out.SetExtension(extContentEncoding, c.Name())
+	return &out, nil
+}
+
+// decompressEvent decompresses the data of e in place if it's compressed. The
+// data of encrypted or claim-checked events is decompressed after it's restored.
+func decompressEvent(e *v2.Event) error {
+	v, ok := e.Extensions()[extContentEncoding]
+	if !ok || isEncrypted(e) || claimCheckRef(e) != "" {
+		return nil
+	}
+	name, _ := v.(string)
+	c, err := lookupPayloadCompressor(name)
+	if err != nil {
+		return err
+	}
+	data, err := c.Decompress(e.Data())
+	if err != nil {
+		return fmt.Errorf("failed to decompress data by %s: %w", name, err)
+	}
+	e.DataEncoded = data
+	e.SetExtension(extContentEncoding, nil)
+	return nil
+}
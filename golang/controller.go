@@ -19,11 +19,12 @@ import (
 )
 
 func (c *client) Controller() Controller {
-	return &controller{controller: c.controller}
+	return &controller{controller: c.controller, cache: c.cache}
 }
 
 type controller struct {
 	controller proxypb.ControllerProxyClient
+	cache      *metadataCache
 }
 
 func (c *controller) Event() Event {
@@ -31,13 +32,13 @@ func (c *controller) Event() Event {
 }
 
 func (c *controller) Eventbus() Eventbus {
-	return &eventbus{controller: c.controller}
+	return &eventbus{controller: c.controller, cache: c.cache}
 }
 
 func (c *controller) Namespace() Namespace {
-	return &namespace{controller: c.controller}
+	return &namespace{controller: c.controller, cache: c.cache}
 }
 
 func (c *controller) Subscription() Subscription {
-	return &subscription{controller: c.controller}
+	return &subscription{controller: c.controller, cache: c.cache}
 }
@@ -0,0 +1,293 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vanus
+
+import (
+	// standard libraries.
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	// third-party libraries.
+	v2 "github.com/cloudevents/sdk-go/v2"
+)
+
+const (
+	EncryptionAES256GCM = "AES256GCM"
+
+	// extEncryptionKeyID is the ID of the key which wraps the data key.
+	extEncryptionKeyID = "encryptionkeyid"
+	// extEncryptionAlg is the algorithm which encrypts the data.
+	extEncryptionAlg = "encryptionalg"
+	// extEncryptedDataKey is the wrapped data key, encoded by base64.
+	extEncryptedDataKey = "encrypteddatakey"
+	// extEncryptedType is the datacontenttype of the plaintext.
+	extEncryptedType = "encryptedtype"
+
+	contentTypeOctetStream = "application/octet-stream"
+	dataKeySize            = 32
+)
+
+// KeyProvider wraps the data keys of encrypted events by key encryption keys,
+// which are kept outside of the Vanus cluster, e.g. in a KMS or a keyring.
+type KeyProvider interface {
+	// WrapKey encrypts the data key by the current key encryption key, and
+	// returns the ID of the key encryption key.
+	WrapKey(ctx context.Context, dataKey []byte) (keyID string, wrapped []byte, err error)
+	// UnwrapKey decrypts the data key by the key encryption key of keyID, which
+	// may have been rotated.
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// Keyring is a KeyProvider backed by a local file, e.g.
+//
+//	{"primary": "2023-06", "keys": {"2023-01": "<base64>", "2023-06": "<base64>"}}
+//
+// New data keys are wrapped by the primary key, the others are kept to decrypt
+// the events published before rotation.
+type Keyring struct {
+	path string
+	mu   sync.RWMutex
+	file keyringFile
+}
+
+type keyringFile struct {
+	Primary string            `json:"primary"`
+	Keys    map[string][]byte `json:"keys"`
+}
+
+// Make sure Keyring implements KeyProvider.
+var _ KeyProvider = (*Keyring)(nil)
+
+// OpenKeyring loads the keyring file at path, which must exist, see
+// CreateKeyring.
+func OpenKeyring(path string) (*Keyring, error) {
+	k := &Keyring{path: path}
+	if err := k.Reload(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// CreateKeyring creates the keyring file at path with a new primary key, it
+// fails if the file exists.
+func CreateKeyring(path string) (*Keyring, error) {
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("keyring %s: %w", path, os.ErrExist)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	k := &Keyring{path: path}
+	if _, err := k.Rotate(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Reload reads the keyring file again, e.g. after it's rotated by another
+// process.
+func (k *Keyring) Reload() error {
+	data, err := os.ReadFile(k.path)
+	if err != nil {
+		return err
+	}
+	var f keyringFile
+	if err = json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("invalid keyring %s: %w", k.path, err)
+	}
+	if _, ok := f.Keys[f.Primary]; !ok {
+		return fmt.Errorf("%w: primary key %s of keyring %s", ErrKeyNotFound, f.Primary, k.path)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.file = f
+	return nil
+}
+
+// Rotate generates a new primary key and persists the keyring, the previous
+// keys are retained for decryption. It returns the ID of the new key.
+func (k *Keyring) Rotate() (string, error) {
+	key := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	f := keyringFile{
+		Primary: time.Now().UTC().Format("20060102T150405.000000000"),
+		Keys:    make(map[string][]byte, len(k.file.Keys)+1),
+	}
+	for id, v := range k.file.Keys {
+		f.Keys[id] = v
+	}
+	f.Keys[f.Primary] = key
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	tmp := k.path + ".tmp"
+	if err = os.MkdirAll(filepath.Dir(k.path), 0o700); err != nil {
+		return "", err
+	}
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return "", err
+	}
+	if err = os.Rename(tmp, k.path); err != nil {
+		return "", err
+	}
+	k.file = f
+	return f.Primary, nil
+}
+
+func (k *Keyring) WrapKey(_ context.Context, dataKey []byte) (string, []byte, error) {
+	k.mu.RLock()
+	id, kek := k.file.Primary, k.file.Keys[k.file.Primary]
+	k.mu.RUnlock()
+
+	wrapped, err := sealAESGCM(kek, dataKey, []byte(id))
+	if err != nil {
+		return "", nil, err
+	}
+	return id, wrapped, nil
+}
+
+func (k *Keyring) UnwrapKey(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	k.mu.RLock()
+	kek, ok := k.file.Keys[keyID]
+	k.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, keyID)
+	}
+	return openAESGCM(kek, wrapped, []byte(keyID))
+}
+
+// sealAESGCM returns nonce || ciphertext.
+func sealAESGCM(key, plaintext, aad []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func openAESGCM(key, sealed, aad []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrDecryption
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], aad)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDecryption, err)
+	}
+	return plaintext, nil
+}
+
+// encryptEvent returns a copy of e whose data is encrypted by a new data key,
+// the data key is wrapped by kp and carried by extensions.
+func encryptEvent(ctx context.Context, e *v2.Event, kp KeyProvider) (*v2.Event, error) {
+	if len(e.Data()) == 0 {
+		return e, nil
+	}
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, err
+	}
+	keyID, wrapped, err := kp.WrapKey(ctx, dataKey)
+	if err != nil {
+		return nil, err
+	}
+	// The event id is authenticated, so the data can't be moved to other events.
+	data, err := sealAESGCM(dataKey, e.Data(), []byte(e.ID()))
+	if err != nil {
+		return nil, err
+	}
+
+	out := e.Clone()
+	out.DataEncoded = data
+	if ct := e.DataContentType(); ct != "" {
+		out.SetExtension(extEncryptedType, ct)
+	}
+	out.SetDataContentType(contentTypeOctetStream)
+	out.SetExtension(extEncryptionKeyID, keyID)
+	out.SetExtension(extEncryptionAlg, EncryptionAES256GCM)
+	out.SetExtension(extEncryptedDataKey, base64.StdEncoding.EncodeToString(wrapped))
+	return &out, nil
+}
+
+func isEncrypted(e *v2.Event) bool {
+	_, ok := e.Extensions()[extEncryptionKeyID]
+	return ok
+}
+
+// decryptEvent decrypts the data of e in place if it's encrypted.
+func decryptEvent(ctx context.Context, e *v2.Event, kp KeyProvider) error {
+	if !isEncrypted(e) {
+		return nil
+	}
+	ext := e.Extensions()
+	keyID, _ := ext[extEncryptionKeyID].(string)
+	alg, _ := ext[extEncryptionAlg].(string)
+	if alg != EncryptionAES256GCM {
+		return fmt.Errorf("%w: unsupported algorithm %s", ErrDecryption, alg)
+	}
+	encoded, _ := ext[extEncryptedDataKey].(string)
+	wrapped, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("%w: invalid data key: %s", ErrDecryption, err)
+	}
+	dataKey, err := kp.UnwrapKey(ctx, keyID, wrapped)
+	if err != nil {
+		return err
+	}
+	data, err := openAESGCM(dataKey, e.Data(), []byte(e.ID()))
+	if err != nil {
+		return err
+	}
+
+	e.DataEncoded = data
+	ct, _ := ext[extEncryptedType].(string)
+	e.SetDataContentType(ct)
+	for _, name := range []string{extEncryptionKeyID, extEncryptionAlg, extEncryptedDataKey, extEncryptedType} {
+		e.SetExtension(name, nil)
+	}
+	return decompressEvent(e)
+}
@@ -17,12 +17,30 @@ package vanus
 import "errors"
 
 var (
-	ErrNamespaceNotFound    = errors.New("namespace is not found")
-	ErrEventbusNotFound     = errors.New("eventbus is not found")
-	ErrEventbusExist        = errors.New("eventbus already exists")
-	ErrEventbusIsZero       = errors.New("eventbus id can't be 0")
-	ErrInvalidArguments     = errors.New("invalid arguments")
-	ErrSubscriptionExist    = errors.New("subscription already exists")
-	ErrSubscriptionNotFound = errors.New("subscription is not found")
-	ErrSubscriptionIDIsZero = errors.New("subscription id can't be 0")
+	ErrNamespaceNotFound      = errors.New("namespace is not found")
+	ErrNamespaceExist         = errors.New("namespace already exists")
+	ErrEventbusNotFound       = errors.New("eventbus is not found")
+	ErrEventbusExist          = errors.New("eventbus already exists")
+	ErrEventbusIsZero         = errors.New("eventbus id can't be 0")
+	ErrEventbusConflict       = errors.New("eventbus exists with a different config")
+	ErrLabelsUnsupported      = errors.New("labels aren't supported by the controller")
+	ErrInvalidArguments       = errors.New("invalid arguments")
+	ErrSubscriptionExist      = errors.New("subscription already exists")
+	ErrSubscriptionNotFound   = errors.New("subscription is not found")
+	ErrSubscriptionIDIsZero   = errors.New("subscription id can't be 0")
+	ErrSubscriptionConflict   = errors.New("subscription exists with a different config")
+	ErrCodecNotFound          = errors.New("codec is not found")
+	ErrSchemaNotFound         = errors.New("schema is not found")
+	ErrSchemaValidation       = errors.New("event data doesn't match the schema")
+	ErrCompressorNotFound     = errors.New("compressor is not found")
+	ErrKeyNotFound            = errors.New("encryption key is not found")
+	ErrDecryption             = errors.New("failed to decrypt event data")
+	ErrInvalidBlobRef         = errors.New("invalid blob reference")
+	ErrBlobNotFound           = errors.New("blob is not found")
+	ErrClusterUnhealthy       = errors.New("cluster is unhealthy")
+	ErrFormatNotFound         = errors.New("export format is not found")
+	ErrBindingNoEventbus      = errors.New("binding has no eventbus to send to")
+	ErrBindingNoSubscription  = errors.New("binding has no subscription to receive from")
+	ErrSubscriptionRegistered = errors.New("subscription is already registered")
+	ErrSubscriberClosed       = errors.New("subscriber is closed")
 )
@@ -39,6 +39,7 @@ func (e *event) Get(ctx context.Context, opts ...EventOption) (*proxypb.GetEvent
 	}
 	return e.controller.GetEvent(ctx, &proxypb.GetEventRequest{
 		EventbusId: o.eventbusID,
+		EventlogId: o.eventlogID,
 		EventId:    o.eventID,
 		Offset:     o.offset,
 		Number:     o.number,
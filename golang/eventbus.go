@@ -17,10 +17,12 @@ package vanus
 import (
 	// standard libraries.
 	"context"
+	"fmt"
 	"strings"
 	"time"
 
 	// third-party libraries.
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 
 	// first-party libraries.
@@ -30,8 +32,80 @@ import (
 	proxypb "github.com/vanus-labs/vanus/api/proxy"
 )
 
+// defaultLogNumber is the number of eventlogs of new eventbuses.
+const defaultLogNumber = 1
+
+// EventbusInfo is the metadata of an eventbus.
+type EventbusInfo struct {
+	ID          ID             `json:"id"`
+	Name        string         `json:"name"`
+	NamespaceID ID             `json:"namespace_id"`
+	LogNumber   int            `json:"log_number"`
+	Description string         `json:"description,omitempty"`
+	Eventlogs   []EventlogInfo `json:"eventlogs,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+
+	pb *metapb.Eventbus
+}
+
+// EventlogInfo is the metadata of an eventlog of an eventbus.
+type EventlogInfo struct {
+	ID              ID       `json:"id"`
+	SegmentNumber   int      `json:"segment_number"`
+	ServerAddresses []string `json:"server_addresses,omitempty"`
+}
+
+// NewEventbusInfo returns the metadata of pb, e.g. of an eventbus returned by
+// Eventbus.Create.
+func NewEventbusInfo(pb *metapb.Eventbus) *EventbusInfo {
+	info := &EventbusInfo{
+		ID:          NewID(pb.Id),
+		Name:        pb.Name,
+		NamespaceID: NewID(pb.NamespaceId),
+		LogNumber:   int(pb.LogNumber),
+		Description: pb.Description,
+		CreatedAt:   time.UnixMilli(pb.CreatedAt),
+		UpdatedAt:   time.UnixMilli(pb.UpdatedAt),
+		pb:          pb,
+	}
+	for _, l := range pb.Logs {
+		info.Eventlogs = append(info.Eventlogs, EventlogInfo{
+			ID:              NewID(l.EventlogId),
+			SegmentNumber:   int(l.CurrentSegmentNumbers),
+			ServerAddresses: l.ServerAddress,
+		})
+	}
+	return info
+}
+
+// Proto returns the metadata in protobuf.
+func (i *EventbusInfo) Proto() *metapb.Eventbus {
+	return i.pb
+}
+
+func (o eventbusOptions) logNumberOrDefault() int {
+	if o.logNumber == 0 {
+		return defaultLogNumber
+	}
+	return o.logNumber
+}
+
+// diff returns the properties of o which differ from info.
+func (o eventbusOptions) diff(info *EventbusInfo) []string {
+	var diff []string
+	if info.LogNumber != o.logNumberOrDefault() {
+		diff = append(diff, fmt.Sprintf("log number %d, expected %d", info.LogNumber, o.logNumberOrDefault()))
+	}
+	if o.description != nil && info.Description != *o.description {
+		diff = append(diff, "description")
+	}
+	return diff
+}
+
 type eventbus struct {
 	controller proxypb.ControllerProxyClient
+	cache      *metadataCache
 }
 
 // Make sure eventbus implements Eventbus.
@@ -53,24 +127,28 @@ func (eb *eventbus) LookupOffset(ctx context.Context, timestamp time.Time, opts
 	return resp, nil
 }
 
-func (eb *eventbus) List(ctx context.Context) ([]*metapb.Eventbus, error) {
-	res, err := eb.controller.ListEventbus(ctx, &ctrlpb.ListEventbusRequest{})
+func (eb *eventbus) Get(ctx context.Context, opts ...EventbusOption) (*metapb.Eventbus, error) {
+	return eb.get(ctx, newEventbusOptions(opts...))
+}
+
+func (eb *eventbus) GetInfo(ctx context.Context, opts ...EventbusOption) (*EventbusInfo, error) {
+	pb, err := eb.Get(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
-	return res.GetEventbus(), nil
-}
-
-func (eb *eventbus) Get(ctx context.Context, opts ...EventbusOption) (*metapb.Eventbus, error) {
-	return eb.get(ctx, newEventbusOptions(opts...))
+	return NewEventbusInfo(pb), nil
 }
 
 func (eb *eventbus) Create(ctx context.Context, opts ...EventbusOption) (*metapb.Eventbus, error) {
 	ebOpts := newEventbusOptions(opts...)
-	if ebOpts.eventbusName == "" || ebOpts.namespace == "" {
+	if ebOpts.eventbusName == "" || ebOpts.namespace == "" || ebOpts.logNumber < 0 {
 		return nil, ErrInvalidArguments
 	}
-	_, err := eb.get(ctx, ebOpts)
+	if len(ebOpts.labels) != 0 {
+		return nil, ErrLabelsUnsupported
+	}
+	// The cache may still have the eventbus which is deleted elsewhere.
+	_, err := eb.fetch(ctx, ebOpts)
 	if err != ErrEventbusNotFound {
 		if err != nil {
 			return nil, err
@@ -83,12 +161,44 @@ func (eb *eventbus) Create(ctx context.Context, opts ...EventbusOption) (*metapb
 		return nil, err
 	}
 
-	return eb.controller.CreateEventbus(ctx, &ctrlpb.CreateEventbusRequest{
+	req := &ctrlpb.CreateEventbusRequest{
 		Id:          ebOpts.eventbusID,
 		Name:        ebOpts.eventbusName,
 		NamespaceId: ns.Id,
-		LogNumber:   1,
-	})
+		LogNumber:   int32(ebOpts.logNumberOrDefault()),
+	}
+	if ebOpts.description != nil {
+		req.Description = *ebOpts.description
+	}
+	return eb.controller.CreateEventbus(ctx, req)
+}
+
+func (eb *eventbus) Ensure(ctx context.Context, opts ...EventbusOption) (*EventbusInfo, error) {
+	ebOpts := newEventbusOptions(opts...)
+	if ebOpts.eventbusName == "" || ebOpts.namespace == "" {
+		return nil, ErrInvalidArguments
+	}
+	if len(ebOpts.labels) != 0 {
+		return nil, ErrLabelsUnsupported
+	}
+	// The existence is checked with the controller, bypassing the cache.
+	pb, err := eb.fetch(ctx, ebOpts)
+	if err == ErrEventbusNotFound {
+		pb, err = eb.Create(ctx, opts...)
+		if err == ErrEventbusExist || errors.Is(err, errors.ErrResourceAlreadyExist) {
+			// Created by a concurrent caller.
+			pb, err = eb.fetch(ctx, ebOpts)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	info := NewEventbusInfo(pb)
+	if diff := ebOpts.diff(info); len(diff) != 0 {
+		return nil, fmt.Errorf("%w: %s/%s differs in %s",
+			ErrEventbusConflict, ebOpts.namespace, ebOpts.eventbusName, strings.Join(diff, ", "))
+	}
+	return info, nil
 }
 
 func (eb *eventbus) Delete(ctx context.Context, opts ...EventbusOption) error {
@@ -109,9 +219,18 @@ func (eb *eventbus) Delete(ctx context.Context, opts ...EventbusOption) error {
 	if err != nil {
 		return err
 	}
+	eb.invalidate(pb.Id)
 	return nil
 }
 
+// invalidate removes the eventbus of the ID from the cache, by all its names.
+func (eb *eventbus) invalidate(id uint64) {
+	eb.cache.invalidateFunc(func(_ string, value interface{}) bool {
+		pb, ok := value.(*metapb.Eventbus)
+		return ok && pb.Id == id
+	})
+}
+
 func (eb *eventbus) CheckHealth(ctx context.Context, opts ...EventbusOption) error {
 	o := newEventbusOptions(opts...)
 	if o.eventbusID == 0 {
@@ -124,15 +243,36 @@ func (eb *eventbus) CheckHealth(ctx context.Context, opts ...EventbusOption) err
 }
 
 func (eb *eventbus) get(ctx context.Context, opts eventbusOptions) (*metapb.Eventbus, error) {
+	key := eventbusCacheKey(opts.namespace, opts.eventbusName, opts.eventbusID)
+	if opts.eventbusID != 0 {
+		key = eventbusCacheKey("", "", opts.eventbusID)
+	}
+	// Callers get copies, so they can't change the cached metadata.
+	if value, ok := eb.cache.get(key); ok {
+		return proto.Clone(value.(*metapb.Eventbus)).(*metapb.Eventbus), nil
+	}
+	pb, err := eb.fetch(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	keys := []string{key, eventbusCacheKey("", "", pb.Id)}
+	if opts.namespace != "" && pb.Name != "" {
+		keys = append(keys, eventbusCacheKey(opts.namespace, pb.Name, 0))
+	}
+	eb.cache.set(proto.Clone(pb), keys...)
+	return pb, nil
+}
+
+func (eb *eventbus) fetch(ctx context.Context, opts eventbusOptions) (*metapb.Eventbus, error) {
 	if opts.eventbusID == 0 {
 		if opts.namespace != "" && opts.eventbusName != "" {
-			ns, err := eb.controller.GetNamespaceWithHumanFriendly(ctx, wrapperspb.String(opts.namespace))
+			nsID, err := namespaceID(ctx, eb.controller, eb.cache, opts.namespace)
 			if err != nil {
 				return nil, ErrNamespaceNotFound
 			}
 
 			eb, err := eb.controller.GetEventbusWithHumanFriendly(ctx, &ctrlpb.GetEventbusWithHumanFriendlyRequest{
-				NamespaceId:  ns.Id,
+				NamespaceId:  nsID,
 				EventbusName: opts.eventbusName,
 			})
 			switch {
@@ -37,7 +37,7 @@ func main() {
 		panic(err)
 	}
 
-	es, err := c.Controller().Event().Get(context.Background(), vanus.WithBatchEvents(eb.Id, 0, 1))
+	es, err := c.Controller().Event().Get(context.Background(), vanus.WithBatchEvents(vanus.NewID(eb.Id), 0, 1))
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,360 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vanus
+
+import (
+	// standard libraries.
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	// third-party libraries.
+	v2 "github.com/cloudevents/sdk-go/v2"
+)
+
+const (
+	// FormatNDJSON is the format of structured CloudEvents in JSON lines.
+	FormatNDJSON = "ndjson"
+
+	// ManifestFile is the name of the manifest in an export directory.
+	ManifestFile = "manifest.json"
+
+	manifestVersion = 1
+	maxNDJSONLine   = 16 * 1024 * 1024
+)
+
+// ExportFormat is a file format of exported events, NDJSON is built in, and
+// others like Parquet can be registered by RegisterExportFormat.
+type ExportFormat interface {
+	Name() string
+	// Extension is the extension of files, without the dot.
+	Extension() string
+	NewWriter(w io.Writer) (EventWriter, error)
+	NewReader(r io.Reader) (EventReader, error)
+}
+
+// EventWriter writes events to a file, Close flushes it but doesn't close the
+// underlying writer.
+type EventWriter interface {
+	Write(e *v2.Event) error
+	Close() error
+}
+
+// EventReader reads events from a file, Read returns io.EOF at the end.
+type EventReader interface {
+	Read() (*v2.Event, error)
+}
+
+var exportFormats sync.Map
+
+// RegisterExportFormat registers a format for its name, which is used by
+// exports and imports of this process.
+func RegisterExportFormat(f ExportFormat) {
+	exportFormats.Store(f.Name(), f)
+}
+
+func lookupExportFormat(name string) (ExportFormat, error) {
+	v, ok := exportFormats.Load(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrFormatNotFound, name)
+	}
+	return v.(ExportFormat), nil
+}
+
+func init() {
+	RegisterExportFormat(ndjsonFormat{})
+}
+
+type ndjsonFormat struct{}
+
+func (ndjsonFormat) Name() string {
+	return FormatNDJSON
+}
+
+func (ndjsonFormat) Extension() string {
+	return FormatNDJSON
+}
+
+func (ndjsonFormat) NewWriter(w io.Writer) (EventWriter, error) {
+	bw := bufio.NewWriter(w)
+	return &ndjsonWriter{w: bw, enc: json.NewEncoder(bw)}, nil
+}
+
+func (ndjsonFormat) NewReader(r io.Reader) (EventReader, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxNDJSONLine)
+	return &ndjsonReader{scanner: scanner}, nil
+}
+
+type ndjsonWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func (w *ndjsonWriter) Write(e *v2.Event) error {
+	// Encode appends a newline.
+	return w.enc.Encode(e)
+}
+
+func (w *ndjsonWriter) Close() error {
+	return w.w.Flush()
+}
+
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (r *ndjsonReader) Read() (*v2.Event, error) {
+	for r.scanner.Scan() {
+		r.line++
+		if len(r.scanner.Bytes()) == 0 {
+			continue
+		}
+		e := v2.NewEvent()
+		if err := json.Unmarshal(r.scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", r.line, err)
+		}
+		return &e, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// ExportManifest describes the files of an export.
+type ExportManifest struct {
+	Version    int        `json:"version"`
+	Namespace  string     `json:"namespace,omitempty"`
+	Eventbus   string     `json:"eventbus"`
+	EventbusID ID         `json:"eventbus_id"`
+	Format     string     `json:"format"`
+	From       *time.Time `json:"from,omitempty"`
+	To         *time.Time `json:"to,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Events     int64      `json:"events"`
+	// Eventlogs are exported to a file each.
+	Eventlogs []ExportedEventlog `json:"eventlogs"`
+}
+
+// ExportedEventlog is the offsets of an eventlog exported to a file, from
+// StartOffset to EndOffset exclusively.
+type ExportedEventlog struct {
+	ID          ID     `json:"id"`
+	StartOffset int64  `json:"start_offset"`
+	EndOffset   int64  `json:"end_offset"`
+	Events      int64  `json:"events"`
+	File        string `json:"file"`
+}
+
+// LoadManifest reads the manifest of the export directory.
+func LoadManifest(dir string) (*ExportManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	m := &ExportManifest{}
+	if err = json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("invalid manifest of %s: %w", dir, err)
+	}
+	if m.Version != manifestVersion {
+		return nil, fmt.Errorf("%w: manifest version %d", ErrInvalidArguments, m.Version)
+	}
+	return m, nil
+}
+
+type ExportOption func(opt *exportOptions)
+
+type exportOptions struct {
+	format      string
+	from, to    time.Time
+	startOffset int64
+	endOffset   int64
+}
+
+// WithExportFormat sets the format of files, FormatNDJSON by default.
+func WithExportFormat(name string) ExportOption {
+	return func(opt *exportOptions) {
+		opt.format = name
+	}
+}
+
+// WithExportTimeRange exports the events stored from from until to, either of
+// which may be zero for unbounded.
+func WithExportTimeRange(from, to time.Time) ExportOption {
+	return func(opt *exportOptions) {
+		opt.from, opt.to = from, to
+	}
+}
+
+// WithExportOffsetRange exports the events from start to end exclusively of
+// every eventlog, end is unbounded if it's negative.
+func WithExportOffsetRange(start, end int64) ExportOption {
+	return func(opt *exportOptions) {
+		opt.startOffset, opt.endOffset = start, end
+	}
+}
+
+// Export writes the events of the eventbus to a file per eventlog of dir, and
+// the manifest at last, so a directory without manifest is incomplete.
+func Export(ctx context.Context, c Client, dir string, eventbus EventbusOption, opts ...ExportOption) (*ExportManifest, error) {
+	o := exportOptions{format: FormatNDJSON, endOffset: -1}
+	for _, apply := range opts {
+		apply(&o)
+	}
+	format, err := lookupExportFormat(o.format)
+	if err != nil {
+		return nil, err
+	}
+	ebOpts := newEventbusOptions(eventbus)
+	stats, err := c.Controller().Eventbus().Stats(ctx, eventbus)
+	if err != nil {
+		return nil, err
+	}
+	starts, err := lookupOffsets(ctx, c, eventbus, o.from)
+	if err != nil {
+		return nil, err
+	}
+	ends, err := lookupOffsets(ctx, c, eventbus, o.to)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	m := &ExportManifest{
+		Version:    manifestVersion,
+		Namespace:  ebOpts.namespace,
+		Eventbus:   stats.Name,
+		EventbusID: stats.ID,
+		Format:     format.Name(),
+		CreatedAt:  time.Now(),
+	}
+	if !o.from.IsZero() {
+		m.From = &o.from
+	}
+	if !o.to.IsZero() {
+		m.To = &o.to
+	}
+	for _, l := range stats.Eventlogs {
+		el := ExportedEventlog{
+			ID:          l.ID,
+			StartOffset: maxInt64(l.EarliestOffset, o.startOffset),
+			EndOffset:   l.LatestOffset,
+			File:        l.ID.Hex() + "." + format.Extension(),
+		}
+		if offset, ok := starts[uint64(l.ID)]; ok && offset > el.StartOffset {
+			el.StartOffset = offset
+		}
+		if offset, ok := ends[uint64(l.ID)]; ok && offset >= 0 && offset < el.EndOffset {
+			el.EndOffset = offset
+		}
+		if o.endOffset >= 0 && o.endOffset < el.EndOffset {
+			el.EndOffset = o.endOffset
+		}
+		// Reading may stop before the planned end offset, if no more events are
+		// returned, so the manifest records the offset which is reached.
+		if el.EndOffset, err = exportEventlog(ctx, c, format, filepath.Join(dir, el.File), stats.ID, el); err != nil {
+			return nil, err
+		}
+		el.Events = el.EndOffset - el.StartOffset
+		m.Events += el.Events
+		m.Eventlogs = append(m.Eventlogs, el)
+	}
+	return m, writeManifest(dir, m)
+}
+
+// lookupOffsets returns the offsets of eventlogs at t, or nil if t is zero.
+func lookupOffsets(ctx context.Context, c Client, eventbus EventbusOption, t time.Time) (map[uint64]int64, error) {
+	if t.IsZero() {
+		return nil, nil
+	}
+	res, err := c.Controller().Eventbus().LookupOffset(ctx, t, eventbus)
+	if err != nil {
+		return nil, err
+	}
+	return res.Offsets, nil
+}
+
+// exportEventlog exports the events of el from StartOffset up to EndOffset, and
+// returns the offset after the last exported event.
+func exportEventlog(ctx context.Context, c Client, format ExportFormat, path string,
+	eventbusID ID, el ExportedEventlog) (int64, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	w, err := format.NewWriter(f)
+	if err != nil {
+		return 0, err
+	}
+	offset := el.StartOffset
+	for offset < el.EndOffset {
+		number := el.EndOffset - offset
+		if number > maximumNumberPerGetRequest {
+			number = maximumNumberPerGetRequest
+		}
+		res, err := c.Controller().Event().Get(ctx,
+			WithBatchEvents(eventbusID, offset, int32(number)), WithEventlog(el.ID))
+		if err != nil {
+			return offset, err
+		}
+		if len(res.Events) == 0 {
+			break
+		}
+		for _, raw := range res.Events {
+			e := v2.NewEvent()
+			if err = json.Unmarshal(raw.Value, &e); err != nil {
+				return offset, fmt.Errorf("eventlog %s offset %d: %w", el.ID, offset, err)
+			}
+			if err = w.Write(&e); err != nil {
+				return offset, err
+			}
+			offset++
+		}
+	}
+	if err = w.Close(); err != nil {
+		return offset, err
+	}
+	return offset, f.Sync()
+}
+
+func writeManifest(dir string, m *ExportManifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, ManifestFile+".tmp")
+	if err = os.WriteFile(tmp, data, 0o644); err != nil { //nolint:gosec // not secret.
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, ManifestFile))
+}
+
+func maxInt64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,423 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vanus
+
+import (
+	// standard libraries.
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	// third-party libraries.
+	v2 "github.com/cloudevents/sdk-go/v2"
+	"google.golang.org/grpc/connectivity"
+)
+
+const (
+	defaultCheckInterval     = 5 * time.Second
+	defaultFailureThreshold  = 3
+	defaultRecoveryThreshold = 12
+)
+
+// FailoverOptions configures a client of multiple clusters.
+type FailoverOptions struct {
+	// Clusters are in the order of preference, the first is the primary.
+	Clusters []*ClientOptions
+	// CheckInterval is the interval of health checks, 5s if it's 0.
+	CheckInterval time.Duration
+	// FailureThreshold is the number of consecutive failed checks of the
+	// active cluster before failing over, 3 if it's 0.
+	FailureThreshold int
+	// RecoveryThreshold is the number of consecutive healthy checks of a
+	// preferred cluster before failing back to it, 12 if it's 0. It's larger
+	// than FailureThreshold, so a flapping cluster doesn't take traffic back.
+	RecoveryThreshold int
+	// HealthCheck checks a cluster, e.g. by Eventbus.CheckHealth. By default,
+	// a cluster is healthy if any of its connections is ready.
+	HealthCheck func(ctx context.Context, c Client) error
+	// OnFailover is called on every switch of the active cluster.
+	OnFailover func(FailoverEvent)
+}
+
+// FailoverEvent is a switch of the active cluster.
+type FailoverEvent struct {
+	From         int       `json:"from"`
+	To           int       `json:"to"`
+	FromEndpoint string    `json:"from_endpoint"`
+	ToEndpoint   string    `json:"to_endpoint"`
+	Time         time.Time `json:"time"`
+	// Reason is the last health check error of From when failing over, and
+	// nil when failing back.
+	Reason error `json:"-"`
+}
+
+// Failback returns whether it's a fail back to a more preferred cluster.
+func (e FailoverEvent) Failback() bool {
+	return e.To < e.From
+}
+
+func (e FailoverEvent) String() string {
+	if e.Reason != nil {
+		return fmt.Sprintf("failover from %s to %s: %s", e.FromEndpoint, e.ToEndpoint, e.Reason)
+	}
+	return fmt.Sprintf("failover from %s to %s", e.FromEndpoint, e.ToEndpoint)
+}
+
+// FailoverClient is a Client of multiple clusters. Publishers publish to the
+// active cluster, and subscribers in active mode follow it; address
+// eventbuses and subscriptions by name, since IDs differ among clusters.
+type FailoverClient struct {
+	options  FailoverOptions
+	clusters []*client
+	health   []clusterHealth
+
+	mu      sync.RWMutex
+	active  int
+	changed chan struct{}
+
+	closeC    chan struct{}
+	closeOnce sync.Once
+}
+
+type clusterHealth struct {
+	failures  int
+	successes int
+	err       error
+}
+
+// Make sure FailoverClient implements Client.
+var _ Client = (*FailoverClient)(nil)
+
+// ConnectFailover connects to all clusters, and checks their health until
+// Disconnect.
+func ConnectFailover(options *FailoverOptions) (*FailoverClient, error) {
+	if len(options.Clusters) == 0 {
+		return nil, fmt.Errorf("%w: no clusters", ErrInvalidArguments)
+	}
+	fc := &FailoverClient{
+		options: *options,
+		health:  make([]clusterHealth, len(options.Clusters)),
+		changed: make(chan struct{}),
+		closeC:  make(chan struct{}),
+	}
+	if fc.options.CheckInterval <= 0 {
+		fc.options.CheckInterval = defaultCheckInterval
+	}
+	if fc.options.FailureThreshold <= 0 {
+		fc.options.FailureThreshold = defaultFailureThreshold
+	}
+	if fc.options.RecoveryThreshold <= 0 {
+		fc.options.RecoveryThreshold = defaultRecoveryThreshold
+	}
+	for _, opts := range options.Clusters {
+		c, err := Connect(opts)
+		if err != nil {
+			_ = fc.disconnect()
+			return nil, err
+		}
+		fc.clusters = append(fc.clusters, c.(*client))
+	}
+	go fc.run()
+	return fc, nil
+}
+
+// Active returns the index of the active cluster in FailoverOptions.Clusters.
+func (fc *FailoverClient) Active() int {
+	fc.mu.RLock()
+	defer fc.mu.RUnlock()
+	return fc.active
+}
+
+// current returns the active cluster, and a channel closed when it changes.
+func (fc *FailoverClient) current() (int, <-chan struct{}) {
+	fc.mu.RLock()
+	defer fc.mu.RUnlock()
+	return fc.active, fc.changed
+}
+
+func (fc *FailoverClient) run() {
+	ticker := time.NewTicker(fc.options.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-fc.closeC:
+			return
+		case <-ticker.C:
+			fc.check()
+		}
+	}
+}
+
+// check checks all clusters, fails over if the active one failed for
+// FailureThreshold times, or fails back to the most preferred one healthy
+// for RecoveryThreshold times.
+func (fc *FailoverClient) check() {
+	for idx := range fc.clusters {
+		ctx, cancel := context.WithTimeout(context.Background(), fc.options.CheckInterval)
+		known, err := fc.probe(ctx, idx)
+		cancel()
+		h := &fc.health[idx]
+		switch {
+		case !known:
+		case err != nil:
+			h.failures++
+			h.successes = 0
+			h.err = err
+		default:
+			h.successes++
+			h.failures = 0
+			h.err = nil
+		}
+	}
+
+	active := fc.Active()
+	for idx := 0; idx < active; idx++ {
+		if fc.health[idx].successes >= fc.options.RecoveryThreshold {
+			fc.switchTo(idx, nil)
+			return
+		}
+	}
+	if fc.health[active].failures < fc.options.FailureThreshold {
+		return
+	}
+	for idx := range fc.clusters {
+		if idx != active && fc.health[idx].successes > 0 {
+			fc.switchTo(idx, fc.health[active].err)
+			return
+		}
+	}
+}
+
+// probe returns whether the health of the cluster is known, e.g. an idle or
+// connecting cluster is unknown, and the error if it's unhealthy.
+func (fc *FailoverClient) probe(ctx context.Context, idx int) (bool, error) {
+	c := fc.clusters[idx]
+	if fc.options.HealthCheck != nil {
+		return true, fc.options.HealthCheck(ctx, c)
+	}
+	known := true
+	states := make([]string, 0, len(c.pool))
+	for _, conn := range c.pool {
+		state := conn.GetState()
+		switch state {
+		case connectivity.Ready:
+			return true, nil
+		case connectivity.Idle:
+			conn.Connect()
+			known = false
+		case connectivity.Connecting:
+			known = false
+		}
+		states = append(states, state.String())
+	}
+	if !known {
+		return false, nil
+	}
+	return true, fmt.Errorf("%w: %s", ErrClusterUnhealthy, strings.Join(states, ", "))
+}
+
+func (fc *FailoverClient) switchTo(idx int, reason error) {
+	fc.mu.Lock()
+	from := fc.active
+	fc.active = idx
+	close(fc.changed)
+	fc.changed = make(chan struct{})
+	fc.mu.Unlock()
+
+	fc.health[from].successes = 0
+	if fc.options.OnFailover != nil {
+		fc.options.OnFailover(FailoverEvent{
+			From:         from,
+			To:           idx,
+			FromEndpoint: fc.clusters[from].endpoint,
+			ToEndpoint:   fc.clusters[idx].endpoint,
+			Time:         time.Now(),
+			Reason:       reason,
+		})
+	}
+}
+
+func (fc *FailoverClient) Publisher(opts ...EventbusOption) Publisher {
+	return &failoverPublisher{
+		client:     fc,
+		options:    opts,
+		publishers: make([]Publisher, len(fc.clusters)),
+	}
+}
+
+func (fc *FailoverClient) Subscriber(opts ...SubscriptionOption) Subscriber {
+	return &failoverSubscriber{
+		client:  fc,
+		options: newSubscriptionOptions(opts...),
+		closeC:  make(chan struct{}),
+	}
+}
+
+// Controller returns the controller of the active cluster.
+func (fc *FailoverClient) Controller() Controller {
+	return fc.clusters[fc.Active()].Controller()
+}
+
+func (fc *FailoverClient) InvalidateMetadata() {
+	for _, c := range fc.clusters {
+		c.InvalidateMetadata()
+	}
+}
+
+func (fc *FailoverClient) Disconnect() error {
+	fc.closeOnce.Do(func() { close(fc.closeC) })
+	return fc.disconnect()
+}
+
+func (fc *FailoverClient) disconnect() error {
+	var err error
+	for _, c := range fc.clusters {
+		if e := c.Disconnect(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// failoverPublisher publishes to the active cluster, by a publisher of each
+// cluster created on first use.
+type failoverPublisher struct {
+	client     *FailoverClient
+	options    []EventbusOption
+	mu         sync.Mutex
+	publishers []Publisher
+}
+
+func (p *failoverPublisher) active() Publisher {
+	idx := p.client.Active()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.publishers[idx] == nil {
+		p.publishers[idx] = p.client.clusters[idx].Publisher(p.options...)
+	}
+	return p.publishers[idx]
+}
+
+func (p *failoverPublisher) Eventbus() string {
+	return p.active().Eventbus()
+}
+
+func (p *failoverPublisher) Publish(ctx context.Context, events ...*v2.Event) error {
+	return p.active().Publish(ctx, events...)
+}
+
+func (p *failoverPublisher) PublishWithResults(ctx context.Context, events ...*v2.Event) ([]PublishResult, error) {
+	return p.active().PublishWithResults(ctx, events...)
+}
+
+func (p *failoverPublisher) PublishAsync(ctx context.Context, events ...*v2.Event) *PublishFuture {
+	return p.active().PublishAsync(ctx, events...)
+}
+
+func (p *failoverPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, pub := range p.publishers {
+		if pub != nil {
+			_ = pub.Close()
+		}
+	}
+	return nil
+}
+
+// failoverSubscriber listens to the active cluster, and moves to the new one
+// on failover if it's in active mode. In passive mode, clusters push to the
+// same sink, so it keeps listening.
+type failoverSubscriber struct {
+	client    *FailoverClient
+	options   subscriptionOptions
+	mu        sync.Mutex
+	current   Subscriber
+	closeC    chan struct{}
+	closeOnce sync.Once
+}
+
+func (s *failoverSubscriber) SubscriptionID() ID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current == nil {
+		return s.options.subscriptionID
+	}
+	return s.current.SubscriptionID()
+}
+
+// Listen listens to the active cluster. In active mode, it follows the active
+// cluster when it changes or the stream breaks, and returns only on Close.
+func (s *failoverSubscriber) Listen(handler func(ctx context.Context, msgs ...Message) error) error {
+	for {
+		idx, changed := s.client.current()
+		sub := s.client.clusters[idx].subscriber(s.options)
+		s.mu.Lock()
+		s.current = sub
+		s.mu.Unlock()
+		if !s.options.activeMode {
+			return sub.Listen(handler)
+		}
+
+		errC := make(chan error, 1)
+		go func() {
+			errC <- sub.Listen(handler)
+		}()
+		select {
+		case <-errC:
+			// The stream broke, e.g. the active cluster is failing, which is
+			// noticed by health checks later. Listen again on the active cluster
+			// once it changes, or after a check interval if it's still healthy.
+			// TODO(wenfeng) log the error.
+			if !s.wait(changed) {
+				return nil
+			}
+		case <-s.closeC:
+			_ = sub.Close()
+			return <-errC
+		case <-changed:
+			_ = sub.Close()
+			<-errC
+		}
+	}
+}
+
+// wait waits for the change of the active cluster or a check interval, it
+// returns false if the subscriber or the client is closed.
+func (s *failoverSubscriber) wait(changed <-chan struct{}) bool {
+	timer := time.NewTimer(s.client.options.CheckInterval)
+	defer timer.Stop()
+	select {
+	case <-s.closeC:
+		return false
+	case <-s.client.closeC:
+		return false
+	case <-changed:
+	case <-timer.C:
+	}
+	return true
+}
+
+func (s *failoverSubscriber) Close() error {
+	s.closeOnce.Do(func() { close(s.closeC) })
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current != nil && !s.options.activeMode {
+		return s.current.Close()
+	}
+	return nil
+}
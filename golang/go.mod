@@ -4,11 +4,18 @@ go 1.18
 
 require (
 	github.com/cloudevents/sdk-go/v2 v2.14.0
+	github.com/golang/snappy v0.0.4
 	github.com/google/uuid v1.3.0
+	github.com/hamba/avro v1.8.0
+	github.com/klauspost/compress v1.15.15
+	github.com/santhosh-tekuri/jsonschema/v5 v5.3.1
 	github.com/vanus-labs/vanus/api v0.0.0-20231221070800-1334a7b9605e
+	github.com/vmihailenco/msgpack/v5 v5.4.1
 	go.uber.org/atomic v1.4.0
+	golang.org/x/time v0.0.0-20210723032227-1f47c861a9ac
 	google.golang.org/grpc v1.54.0
 	google.golang.org/protobuf v1.30.0
+	sigs.k8s.io/yaml v1.3.0
 )
 
 require (
@@ -17,6 +24,7 @@ require (
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
 	go.uber.org/mock v0.4.0 // indirect
 	go.uber.org/multierr v1.1.0 // indirect
 	go.uber.org/zap v1.10.0 // indirect
@@ -26,4 +34,5 @@ require (
 	golang.org/x/text v0.13.0 // indirect
 	google.golang.org/appengine v1.6.7 // indirect
 	google.golang.org/genproto v0.0.0-20230410155749-daa745c078e1 // indirect
+	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
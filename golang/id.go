@@ -0,0 +1,165 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vanus
+
+import (
+	// standard libraries.
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// hexIDLength is the length of IDs formatted by Hex.
+const hexIDLength = 16
+
+// ID is the ID of resources of Vanus, e.g. eventbuses, eventlogs and
+// subscriptions. It's formatted in hex, e.g. 0000002689000012.
+type ID uint64
+
+// Make sure ID implements the interfaces of encoding, flag and database/sql.
+var (
+	_ encoding.TextMarshaler   = ID(0)
+	_ encoding.TextUnmarshaler = (*ID)(nil)
+	_ json.Unmarshaler         = (*ID)(nil)
+	_ flag.Value               = (*ID)(nil)
+	_ sql.Scanner              = (*ID)(nil)
+	_ driver.Valuer            = ID(0)
+)
+
+func NewID(id uint64) ID {
+	return ID(id)
+}
+
+// NewIDFromHex parses the ID formatted by Hex, the "0x" prefix is optional.
+func NewIDFromHex(id string) (ID, error) {
+	if id == "" {
+		return 0, ErrEmptyID
+	}
+	s := strings.TrimPrefix(strings.TrimPrefix(id, "0x"), "0X")
+	i, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid id %q: %w", id, err)
+	}
+	return ID(i), nil
+}
+
+// NewIDFromDecimal parses the ID formatted by Decimal.
+func NewIDFromDecimal(id string) (ID, error) {
+	if id == "" {
+		return 0, ErrEmptyID
+	}
+	i, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid id %q: %w", id, err)
+	}
+	return ID(i), nil
+}
+
+// ParseID parses the text form of IDs, which is hex as formatted by Hex, with
+// or without leading zeros. IDs in decimal are parsed by NewIDFromDecimal.
+func ParseID(id string) (ID, error) {
+	return NewIDFromHex(id)
+}
+
+// NewIDFromString parses the ID in hex.
+//
+// Deprecated: use NewIDFromHex or ParseID.
+func NewIDFromString(id string) (uint64, error) {
+	i, err := NewIDFromHex(id)
+	return uint64(i), err
+}
+
+func (id ID) Hex() string {
+	return fmt.Sprintf("%0*X", hexIDLength, uint64(id))
+}
+
+func (id ID) Decimal() string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+func (id ID) Uint64() uint64 {
+	return uint64(id)
+}
+
+// String returns the ID in hex.
+func (id ID) String() string {
+	return id.Hex()
+}
+
+func (id ID) MarshalText() ([]byte, error) {
+	return []byte(id.Hex()), nil
+}
+
+func (id *ID) UnmarshalText(text []byte) error {
+	i, err := ParseID(string(text))
+	if err != nil {
+		return err
+	}
+	*id = i
+	return nil
+}
+
+// UnmarshalJSON accepts the ID in a string in hex, or in a number.
+func (id *ID) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte{'"'}) {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		return id.UnmarshalText([]byte(s))
+	}
+	var i uint64
+	if err := json.Unmarshal(data, &i); err != nil {
+		return fmt.Errorf("invalid id %s: %w", data, err)
+	}
+	*id = ID(i)
+	return nil
+}
+
+// Set implements flag.Value, the ID is in hex.
+func (id *ID) Set(s string) error {
+	return id.UnmarshalText([]byte(s))
+}
+
+// Value implements driver.Valuer. The ID is stored as a BIGINT, IDs at or above
+// 2^63 are stored as negative numbers, which are restored by Scan.
+func (id ID) Value() (driver.Value, error) {
+	return int64(id), nil
+}
+
+// Scan implements sql.Scanner, it accepts integers, and strings in hex.
+func (id *ID) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*id = 0
+	case int64:
+		*id = ID(v)
+	case uint64:
+		*id = ID(v)
+	case []byte:
+		return id.UnmarshalText(v)
+	case string:
+		return id.UnmarshalText([]byte(v))
+	default:
+		return fmt.Errorf("can't scan %T into ID", src)
+	}
+	return nil
+}
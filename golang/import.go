@@ -0,0 +1,225 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vanus
+
+import (
+	// standard libraries.
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	// third-party libraries.
+	v2 "github.com/cloudevents/sdk-go/v2"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// CheckpointFile is the name of the import progress in an export
+	// directory, which is removed when the import completes.
+	CheckpointFile = "import-checkpoint.json"
+
+	defaultImportBatchSize = 64
+)
+
+type ImportOption func(opt *importOptions)
+
+type importOptions struct {
+	eventbus  EventbusOption
+	batchSize int
+	limiter   *rate.Limiter
+	restart   bool
+}
+
+// WithImportEventbus imports to the eventbus, instead of the exported one.
+func WithImportEventbus(namespace, name string) ImportOption {
+	return func(opt *importOptions) {
+		opt.eventbus = WithEventbus(namespace, name)
+	}
+}
+
+// WithImportBatchSize sets the number of events per publishing, 64 by default.
+func WithImportBatchSize(n int) ImportOption {
+	return func(opt *importOptions) {
+		if n > 0 {
+			opt.batchSize = n
+		}
+	}
+}
+
+// WithImportRateLimit limits the events published per second, with bursts of
+// at most burst events. It's unlimited if eventsPerSecond isn't positive.
+func WithImportRateLimit(eventsPerSecond float64, burst int) ImportOption {
+	return func(opt *importOptions) {
+		if eventsPerSecond <= 0 {
+			opt.limiter = nil
+			return
+		}
+		if burst <= 0 {
+			burst = 1
+		}
+		opt.limiter = rate.NewLimiter(rate.Limit(eventsPerSecond), burst)
+	}
+}
+
+// WithImportRestart ignores the checkpoint of an interrupted import, and
+// imports all events again.
+func WithImportRestart() ImportOption {
+	return func(opt *importOptions) {
+		opt.restart = true
+	}
+}
+
+// ImportStats is the result of an import.
+type ImportStats struct {
+	// Imported is the number of events published by this import.
+	Imported int64 `json:"imported"`
+	// Skipped is the number of events imported before it's resumed.
+	Skipped int64 `json:"skipped"`
+}
+
+// importCheckpoint is the number of events imported of every file.
+type importCheckpoint map[string]int64
+
+// Import publishes the events of an export directory to the eventbus, with
+// their IDs and attributes. The progress is checkpointed in the directory
+// after every batch, so an interrupted import resumes from it.
+func Import(ctx context.Context, c Client, dir string, opts ...ImportOption) (*ImportStats, error) {
+	m, err := LoadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	o := importOptions{batchSize: defaultImportBatchSize}
+	for _, apply := range opts {
+		apply(&o)
+	}
+	if o.eventbus == nil {
+		if m.Namespace == "" {
+			return nil, fmt.Errorf("%w: namespace of %s isn't exported, use WithImportEventbus",
+				ErrInvalidArguments, m.Eventbus)
+		}
+		o.eventbus = WithEventbus(m.Namespace, m.Eventbus)
+	}
+	format, err := lookupExportFormat(m.Format)
+	if err != nil {
+		return nil, err
+	}
+	cp := importCheckpoint{}
+	if !o.restart {
+		if cp, err = loadImportCheckpoint(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	p := c.Publisher(o.eventbus)
+	defer p.Close()
+	stats := &ImportStats{}
+	for _, el := range m.Eventlogs {
+		if err = importFile(ctx, p, format, dir, el.File, cp, o, stats); err != nil {
+			return stats, err
+		}
+	}
+	if err = os.Remove(filepath.Join(dir, CheckpointFile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return stats, err
+	}
+	return stats, nil
+}
+
+func importFile(ctx context.Context, p Publisher, format ExportFormat, dir, file string,
+	cp importCheckpoint, o importOptions, stats *ImportStats) error {
+	f, err := os.Open(filepath.Join(dir, file))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r, err := format.NewReader(f)
+	if err != nil {
+		return err
+	}
+
+	done := cp[file]
+	var (
+		read  int64
+		batch []*v2.Event
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if l := o.limiter; l != nil {
+			for range batch {
+				if err := l.Wait(ctx); err != nil {
+					return err
+				}
+			}
+		}
+		if err := p.Publish(ctx, batch...); err != nil {
+			return err
+		}
+		stats.Imported += int64(len(batch))
+		cp[file] = read
+		batch = batch[:0]
+		return saveImportCheckpoint(dir, cp)
+	}
+	for {
+		e, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		read++
+		if read <= done {
+			stats.Skipped++
+			continue
+		}
+		dropInternalExtensions(e)
+		batch = append(batch, e)
+		if len(batch) >= o.batchSize {
+			if err = flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
+
+func loadImportCheckpoint(dir string) (importCheckpoint, error) {
+	cp := importCheckpoint{}
+	data, err := os.ReadFile(filepath.Join(dir, CheckpointFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cp, json.Unmarshal(data, &cp)
+}
+
+func saveImportCheckpoint(dir string, cp importCheckpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, CheckpointFile+".tmp")
+	if err = os.WriteFile(tmp, data, 0o644); err != nil { //nolint:gosec // not secret.
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, CheckpointFile))
+}
@@ -0,0 +1,157 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vanus
+
+import (
+	// standard libraries.
+	"context"
+	"sort"
+	"time"
+
+	// third-party libraries.
+	v2 "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/types"
+	"go.uber.org/atomic"
+
+	// first-party libraries.
+	proxypb "github.com/vanus-labs/vanus/api/proxy"
+)
+
+// extStoredTime is the time when the event is stored, which is set by Vanus.
+const extStoredTime = "xvanusstime"
+
+// SubscriptionOffset is the committed offset of a subscription on an eventlog.
+type SubscriptionOffset struct {
+	EventlogID ID     `json:"eventlog_id"`
+	Offset     uint64 `json:"offset"`
+}
+
+// SubscriptionLag is the number of events which are stored but not consumed by
+// a subscription.
+type SubscriptionLag struct {
+	Total     int64         `json:"total"`
+	Eventlogs []EventlogLag `json:"eventlogs"`
+}
+
+type EventlogLag struct {
+	EventlogID ID     `json:"eventlog_id"`
+	Committed  uint64 `json:"committed"`
+	Latest     uint64 `json:"latest"`
+	Lag        int64  `json:"lag"`
+}
+
+func (s *subscription) Offsets(ctx context.Context, opts ...SubscriptionOption) ([]SubscriptionOffset, error) {
+	sub, err := s.get(ctx, newSubscriptionOptions(opts...))
+	if err != nil {
+		return nil, err
+	}
+	offsets := make([]SubscriptionOffset, 0, len(sub.Offsets))
+	for _, o := range sub.Offsets {
+		offsets = append(offsets, SubscriptionOffset{EventlogID: NewID(o.EventlogId), Offset: o.Offset})
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i].EventlogID < offsets[j].EventlogID })
+	return offsets, nil
+}
+
+func (s *subscription) Lag(ctx context.Context, opts ...SubscriptionOption) (*SubscriptionLag, error) {
+	sub, err := s.get(ctx, newSubscriptionOptions(opts...))
+	if err != nil {
+		return nil, err
+	}
+	// The offsets of the first events stored after now are the latest offsets.
+	latest, err := s.controller.LookupOffset(ctx, &proxypb.LookupOffsetRequest{
+		EventbusId: sub.EventbusId,
+		Timestamp:  time.Now().Add(time.Minute).UnixMilli(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	committed := make(map[uint64]uint64, len(sub.Offsets))
+	for _, o := range sub.Offsets {
+		committed[o.EventlogId] = o.Offset
+	}
+
+	lag := &SubscriptionLag{Eventlogs: make([]EventlogLag, 0, len(latest.Offsets))}
+	for id, offset := range latest.Offsets {
+		l := EventlogLag{EventlogID: NewID(id), Committed: committed[id]}
+		if offset > 0 {
+			l.Latest = uint64(offset)
+		}
+		if l.Latest > l.Committed {
+			l.Lag = int64(l.Latest - l.Committed)
+		}
+		lag.Total += l.Lag
+		lag.Eventlogs = append(lag.Eventlogs, l)
+	}
+	sort.Slice(lag.Eventlogs, func(i, j int) bool { return lag.Eventlogs[i].EventlogID < lag.Eventlogs[j].EventlogID })
+	return lag, nil
+}
+
+// LagGauge is the lag of a subscriber with WithLagGauge, e.g. for autoscaling
+// consumers and alerting.
+type LagGauge struct {
+	events atomic.Int64
+	// delay is in nanoseconds.
+	delay atomic.Int64
+	// updatedAt is in unix nanoseconds.
+	updatedAt atomic.Int64
+}
+
+// Events returns the number of events which are not consumed, it's refreshed
+// periodically from the committed offsets.
+func (g *LagGauge) Events() int64 {
+	return g.events.Load()
+}
+
+// Delay returns the time between the storing and the receiving of the latest
+// received event.
+func (g *LagGauge) Delay() time.Duration {
+	return time.Duration(g.delay.Load())
+}
+
+// UpdatedAt returns the time when Events was refreshed.
+func (g *LagGauge) UpdatedAt() time.Time {
+	if ns := g.updatedAt.Load(); ns != 0 {
+		return time.Unix(0, ns)
+	}
+	return time.Time{}
+}
+
+func (g *LagGauge) observe(e *v2.Event) {
+	v, ok := e.Extensions()[extStoredTime]
+	if !ok {
+		return
+	}
+	if t, err := types.ToTime(v); err == nil {
+		g.delay.Store(int64(time.Since(t)))
+	}
+}
+
+// poll refreshes Events by s every interval until ctx is done.
+func (g *LagGauge) poll(ctx context.Context, s Subscription, interval time.Duration, opts ...SubscriptionOption) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if lag, err := s.Lag(ctx, opts...); err == nil {
+			g.events.Store(lag.Total)
+			g.updatedAt.Store(time.Now().UnixNano())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
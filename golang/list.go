@@ -0,0 +1,420 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vanus
+
+import (
+	// standard libraries.
+	"context"
+	stderrors "errors"
+	"sort"
+	"strings"
+	"time"
+
+	// third-party libraries.
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	// first-party libraries.
+	ctrlpb "github.com/vanus-labs/vanus/api/controller"
+	"github.com/vanus-labs/vanus/api/errors"
+	metapb "github.com/vanus-labs/vanus/api/meta"
+	proxypb "github.com/vanus-labs/vanus/api/proxy"
+)
+
+const defaultWatchInterval = 5 * time.Second
+
+type SubscriptionState string
+
+const (
+	SubscriptionRunning SubscriptionState = "running"
+	SubscriptionPaused  SubscriptionState = "paused"
+)
+
+// StateOf returns the state of the subscription.
+func StateOf(s *metapb.Subscription) SubscriptionState {
+	if s.Disable {
+		return SubscriptionPaused
+	}
+	return SubscriptionRunning
+}
+
+type ListOption func(opt *listOptions)
+
+type listOptions struct {
+	namespace     string
+	eventbus      string
+	namePrefix    string
+	state         SubscriptionState
+	labels        map[string]string
+	limit         int
+	watchInterval time.Duration
+}
+
+func newListOptions(opts ...ListOption) listOptions {
+	o := listOptions{watchInterval: defaultWatchInterval}
+	for _, apply := range opts {
+		apply(&o)
+	}
+	return o
+}
+
+// InNamespace lists the resources of the namespace.
+func InNamespace(namespace string) ListOption {
+	return func(opt *listOptions) {
+		opt.namespace = namespace
+	}
+}
+
+// OfEventbus lists the subscriptions of the eventbus.
+func OfEventbus(namespace, name string) ListOption {
+	return func(opt *listOptions) {
+		opt.namespace = namespace
+		opt.eventbus = name
+	}
+}
+
+// WithNamePrefix lists the resources whose names start with prefix.
+func WithNamePrefix(prefix string) ListOption {
+	return func(opt *listOptions) {
+		opt.namePrefix = prefix
+	}
+}
+
+// WithState lists the subscriptions in the state.
+func WithState(state SubscriptionState) ListOption {
+	return func(opt *listOptions) {
+		opt.state = state
+	}
+}
+
+// WithLabelSelector lists the resources which have the labels. Eventbuses and
+// subscriptions of the controller have no labels, so List, Iterate and Watch
+// return ErrLabelsUnsupported with it, instead of ignoring it.
+func WithLabelSelector(labels map[string]string) ListOption {
+	return func(opt *listOptions) {
+		opt.labels = labels
+	}
+}
+
+// WithMaxResults returns only the first n resources. The controller doesn't
+// paginate, so all the matched resources are still fetched and the rest are
+// dropped, there is no cursor to list them. 0 means no limit.
+func WithMaxResults(n int) ListOption {
+	return func(opt *listOptions) {
+		opt.limit = n
+	}
+}
+
+// WithWatchInterval sets the interval of polling the controller for Watch.
+func WithWatchInterval(interval time.Duration) ListOption {
+	return func(opt *listOptions) {
+		opt.watchInterval = interval
+	}
+}
+
+// Iterator iterates over listed resources, e.g.
+//
+//	it := c.Controller().Subscription().Iterate(ctx, InNamespace("default"))
+//	for it.Next() {
+//		sub := it.Value()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+//
+// The controller doesn't paginate, so the resources are fetched at once on the
+// first call of Next. The iteration stops with the error of ctx once it's done.
+type Iterator[T any] struct {
+	ctx     context.Context
+	fetch   func(ctx context.Context) ([]T, error)
+	items   []T
+	next    int
+	fetched bool
+	err     error
+}
+
+func newIterator[T any](ctx context.Context, fetch func(ctx context.Context) ([]T, error)) *Iterator[T] {
+	return &Iterator[T]{ctx: ctx, fetch: fetch}
+}
+
+// Next advances to the next resource, it returns false at the end or on error.
+func (it *Iterator[T]) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.err = it.ctx.Err(); it.err != nil {
+		return false
+	}
+	if !it.fetched {
+		it.items, it.err = it.fetch(it.ctx)
+		it.fetched = true
+	}
+	if it.err != nil || it.next >= len(it.items) {
+		return false
+	}
+	it.next++
+	return true
+}
+
+// Value returns the current resource.
+func (it *Iterator[T]) Value() T {
+	return it.items[it.next-1]
+}
+
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// namespaceID resolves the namespace, it's 0 if the namespace is unset.
+func namespaceID(ctx context.Context, c proxypb.ControllerProxyClient,
+	cache *metadataCache, name string) (uint64, error) {
+	if name == "" {
+		return 0, nil
+	}
+	if value, ok := cache.get(cacheNamespace + name); ok {
+		return value.(uint64), nil
+	}
+	ns, err := c.GetNamespaceWithHumanFriendly(ctx, wrapperspb.String(name))
+	if err != nil {
+		if errors.Is(err, errors.ErrResourceNotFound) {
+			return 0, ErrNamespaceNotFound
+		}
+		return 0, err
+	}
+	cache.set(ns.Id, cacheNamespace+name)
+	return ns.Id, nil
+}
+
+func (o listOptions) matchName(name string) bool {
+	return strings.HasPrefix(name, o.namePrefix)
+}
+
+func limit[T any](items []T, n int) []T {
+	if n > 0 && len(items) > n {
+		return items[:n]
+	}
+	return items
+}
+
+func (eb *eventbus) List(ctx context.Context, opts ...ListOption) ([]*metapb.Eventbus, error) {
+	o := newListOptions(opts...)
+	if len(o.labels) != 0 {
+		return nil, ErrLabelsUnsupported
+	}
+	nsID, err := namespaceID(ctx, eb.controller, eb.cache, o.namespace)
+	if err != nil {
+		return nil, err
+	}
+	res, err := eb.controller.ListEventbus(ctx, &ctrlpb.ListEventbusRequest{NamespaceId: nsID})
+	if err != nil {
+		return nil, err
+	}
+	ebs := make([]*metapb.Eventbus, 0, len(res.GetEventbus()))
+	for _, pb := range res.GetEventbus() {
+		if (nsID != 0 && pb.NamespaceId != nsID) || !o.matchName(pb.Name) {
+			continue
+		}
+		ebs = append(ebs, pb)
+	}
+	return limit(ebs, o.limit), nil
+}
+
+func (eb *eventbus) ListInfo(ctx context.Context, opts ...ListOption) ([]*EventbusInfo, error) {
+	ebs, err := eb.List(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]*EventbusInfo, 0, len(ebs))
+	for _, pb := range ebs {
+		infos = append(infos, NewEventbusInfo(pb))
+	}
+	return infos, nil
+}
+
+func (eb *eventbus) Iterate(ctx context.Context, opts ...ListOption) *Iterator[*metapb.Eventbus] {
+	return newIterator(ctx, func(ctx context.Context) ([]*metapb.Eventbus, error) {
+		return eb.List(ctx, opts...)
+	})
+}
+
+func (s *subscription) List(ctx context.Context, opts ...ListOption) ([]*metapb.Subscription, error) {
+	o := newListOptions(opts...)
+	if len(o.labels) != 0 {
+		return nil, ErrLabelsUnsupported
+	}
+	req := &ctrlpb.ListSubscriptionRequest{}
+	var err error
+	if req.NamespaceId, err = namespaceID(ctx, s.controller, s.cache, o.namespace); err != nil {
+		return nil, err
+	}
+	if o.eventbus != "" {
+		eb, err := s.controller.GetEventbusWithHumanFriendly(ctx, &ctrlpb.GetEventbusWithHumanFriendlyRequest{
+			NamespaceId:  req.NamespaceId,
+			EventbusName: o.eventbus,
+		})
+		if err != nil {
+			if errors.Is(err, errors.ErrResourceNotFound) {
+				return nil, ErrEventbusNotFound
+			}
+			return nil, err
+		}
+		req.EventbusId = eb.Id
+	}
+	res, err := s.controller.ListSubscription(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	subs := make([]*metapb.Subscription, 0, len(res.GetSubscription()))
+	for _, sub := range res.GetSubscription() {
+		// Filter again in case the server ignores the conditions.
+		if (req.NamespaceId != 0 && sub.NamespaceId != req.NamespaceId) ||
+			(req.EventbusId != 0 && sub.EventbusId != req.EventbusId) ||
+			!o.matchName(sub.Name) || (o.state != "" && StateOf(sub) != o.state) {
+			continue
+		}
+		subs = append(subs, sub)
+	}
+	return limit(subs, o.limit), nil
+}
+
+func (s *subscription) Iterate(ctx context.Context, opts ...ListOption) *Iterator[*metapb.Subscription] {
+	return newIterator(ctx, func(ctx context.Context) ([]*metapb.Subscription, error) {
+		return s.List(ctx, opts...)
+	})
+}
+
+type ChangeType string
+
+const (
+	ChangeCreated ChangeType = "created"
+	ChangeUpdated ChangeType = "updated"
+	ChangeDeleted ChangeType = "deleted"
+)
+
+// SubscriptionChange is a change of a subscription observed by Watch.
+type SubscriptionChange struct {
+	Type ChangeType `json:"type"`
+	// Subscription is the last observed one if it's deleted.
+	Subscription *metapb.Subscription `json:"subscription"`
+	// Err is the error which stops watching, e.g. the namespace is deleted. It's
+	// the last change before the channel is closed, with no Type and
+	// Subscription.
+	Err error `json:"-"`
+}
+
+func (s *subscription) Watch(ctx context.Context, opts ...ListOption) (<-chan SubscriptionChange, error) {
+	o := newListOptions(opts...)
+	// Watch all of the matched subscriptions.
+	opts = append(opts, WithMaxResults(0))
+	subs, err := s.List(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan SubscriptionChange, len(subs))
+	known := make(map[uint64]*metapb.Subscription, len(subs))
+	for _, sub := range subs {
+		known[sub.Id] = sub
+		ch <- SubscriptionChange{Type: ChangeCreated, Subscription: sub}
+	}
+
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(o.watchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			subs, err := s.List(ctx, opts...)
+			if err != nil {
+				if !isPermanent(err) {
+					// TODO(wenfeng) log, and retry at the next tick.
+					continue
+				}
+				select {
+				case ch <- SubscriptionChange{Err: err}:
+				case <-ctx.Done():
+				}
+				return
+			}
+			for _, c := range diffSubscriptions(known, subs) {
+				select {
+				case ch <- c:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// isPermanent returns whether err won't be resolved by listing again.
+func isPermanent(err error) bool {
+	if isNotFound(err) || stderrors.Is(err, ErrNamespaceNotFound) || stderrors.Is(err, ErrInvalidArguments) {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.NotFound, codes.PermissionDenied, codes.Unauthenticated, codes.Unimplemented:
+		return true
+	default:
+		return false
+	}
+}
+
+// diffSubscriptions returns the changes from known to current, and updates known.
+func diffSubscriptions(known map[uint64]*metapb.Subscription, current []*metapb.Subscription) []SubscriptionChange {
+	var changes []SubscriptionChange
+	seen := make(map[uint64]bool, len(current))
+	for _, sub := range current {
+		seen[sub.Id] = true
+		prev, ok := known[sub.Id]
+		switch {
+		case !ok:
+			changes = append(changes, SubscriptionChange{Type: ChangeCreated, Subscription: sub})
+		case !equalSubscription(prev, sub):
+			changes = append(changes, SubscriptionChange{Type: ChangeUpdated, Subscription: sub})
+		default:
+			continue
+		}
+		known[sub.Id] = sub
+	}
+	var deleted []uint64
+	for id := range known {
+		if !seen[id] {
+			deleted = append(deleted, id)
+		}
+	}
+	sort.Slice(deleted, func(i, j int) bool { return deleted[i] < deleted[j] })
+	for _, id := range deleted {
+		changes = append(changes, SubscriptionChange{Type: ChangeDeleted, Subscription: known[id]})
+		delete(known, id)
+	}
+	return changes
+}
+
+// equalSubscription compares subscriptions except their offsets, which move
+// as events are consumed.
+func equalSubscription(a, b *metapb.Subscription) bool {
+	a = proto.Clone(a).(*metapb.Subscription)
+	b = proto.Clone(b).(*metapb.Subscription)
+	a.Offsets, b.Offsets = nil, nil
+	return proto.Equal(a, b)
+}
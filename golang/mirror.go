@@ -0,0 +1,223 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vanus
+
+import (
+	// standard libraries.
+	"context"
+	"strings"
+	"time"
+
+	// third-party libraries.
+	v2 "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/atomic"
+	"golang.org/x/time/rate"
+)
+
+// internalExtensionPrefix is the prefix of extensions set by Vanus on
+// delivery, which are dropped on mirroring, e.g. xvanusstime.
+const internalExtensionPrefix = "xvanus"
+
+type MirrorOption func(opt *mirrorOptions)
+
+type mirrorOptions struct {
+	filter    func(e *v2.Event) bool
+	transform func(e *v2.Event) (*v2.Event, error)
+	limiter   *rate.Limiter
+}
+
+// WithMirrorFilter mirrors only the events that f returns true.
+func WithMirrorFilter(f func(e *v2.Event) bool) MirrorOption {
+	return func(opt *mirrorOptions) {
+		opt.filter = f
+	}
+}
+
+// WithMirrorTransform transforms events before publishing them, an event is
+// dropped if f returns nil, and redelivered if f returns an error.
+func WithMirrorTransform(f func(e *v2.Event) (*v2.Event, error)) MirrorOption {
+	return func(opt *mirrorOptions) {
+		opt.transform = f
+	}
+}
+
+// WithMirrorRateLimit limits the events published per second, with bursts of
+// at most burst events. It's unlimited if eventsPerSecond isn't positive.
+func WithMirrorRateLimit(eventsPerSecond float64, burst int) MirrorOption {
+	return func(opt *mirrorOptions) {
+		if eventsPerSecond <= 0 {
+			opt.limiter = nil
+			return
+		}
+		if burst <= 0 {
+			burst = 1
+		}
+		opt.limiter = rate.NewLimiter(rate.Limit(eventsPerSecond), burst)
+	}
+}
+
+// MirrorStats are the counters of a Mirror since it runs.
+type MirrorStats struct {
+	Mirrored       int64     `json:"mirrored"`
+	Filtered       int64     `json:"filtered"`
+	Failed         int64     `json:"failed"`
+	LastMirroredAt time.Time `json:"last_mirrored_at"`
+}
+
+// Mirror copies the events of a subscription of the source to an eventbus of
+// the target, which may be another namespace or cluster. IDs and attributes of
+// events are preserved.
+//
+// Events are acknowledged after they're published to the target, so the
+// offsets of the subscription are the checkpoint to resume from, and events
+// may be mirrored more than once if publishing fails.
+type Mirror struct {
+	source  Client
+	target  Client
+	from    SubscriptionOption
+	to      EventbusOption
+	options mirrorOptions
+
+	mirrored       *atomic.Int64
+	filtered       *atomic.Int64
+	failed         *atomic.Int64
+	lastMirroredAt *atomic.Int64
+}
+
+// NewMirror returns a mirror from the subscription of the source to the
+// eventbus of the target, which are addressed by from and to, e.g.
+// WithSubscriptionName and WithEventbus.
+func NewMirror(source, target Client, from SubscriptionOption, to EventbusOption, opts ...MirrorOption) *Mirror {
+	m := &Mirror{
+		source:         source,
+		target:         target,
+		from:           from,
+		to:             to,
+		mirrored:       atomic.NewInt64(0),
+		filtered:       atomic.NewInt64(0),
+		failed:         atomic.NewInt64(0),
+		lastMirroredAt: atomic.NewInt64(0),
+	}
+	for _, apply := range opts {
+		apply(&m.options)
+	}
+	return m
+}
+
+// Run mirrors events until ctx is done or the subscription fails.
+func (m *Mirror) Run(ctx context.Context) error {
+	publisher := m.target.Publisher(m.to)
+	defer publisher.Close()
+	// The subscriber is closed on return, so it's not shared with others.
+	subscriber := uncachedSubscriber(m.source, m.from, WithActiveMode(true))
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- subscriber.Listen(func(_ context.Context, msgs ...Message) error {
+			m.mirror(ctx, publisher, msgs)
+			return nil
+		})
+	}()
+	var err error
+	select {
+	case <-ctx.Done():
+		_ = subscriber.Close()
+	case err = <-errC:
+	}
+	return err
+}
+
+func (m *Mirror) mirror(ctx context.Context, publisher Publisher, msgs []Message) {
+	events := make([]*v2.Event, 0, len(msgs))
+	for _, msg := range msgs {
+		e, err := m.prepare(msg.GetEvent())
+		if err != nil {
+			m.fail(msgs, err)
+			return
+		}
+		if e == nil {
+			m.filtered.Inc()
+			continue
+		}
+		events = append(events, e)
+	}
+	if len(events) != 0 {
+		if l := m.options.limiter; l != nil {
+			for range events {
+				if err := l.Wait(ctx); err != nil {
+					m.fail(msgs, err)
+					return
+				}
+			}
+		}
+		if err := publisher.Publish(ctx, events...); err != nil {
+			m.fail(msgs, err)
+			return
+		}
+		m.mirrored.Add(int64(len(events)))
+		m.lastMirroredAt.Store(time.Now().UnixNano())
+	}
+	for _, msg := range msgs {
+		msg.Success()
+	}
+}
+
+// prepare returns the event to publish, or nil if it's filtered.
+func (m *Mirror) prepare(received *v2.Event) (*v2.Event, error) {
+	e := received.Clone()
+	dropInternalExtensions(&e)
+	if m.options.filter != nil && !m.options.filter(&e) {
+		return nil, nil
+	}
+	if m.options.transform != nil {
+		return m.options.transform(&e)
+	}
+	return &e, nil
+}
+
+// dropInternalExtensions drops the extensions set by Vanus, which are set again
+// by the target.
+func dropInternalExtensions(e *v2.Event) {
+	for name := range e.Extensions() {
+		if strings.HasPrefix(name, internalExtensionPrefix) {
+			e.SetExtension(name, nil)
+		}
+	}
+}
+
+// fail fails the batch, so it's redelivered.
+func (m *Mirror) fail(msgs []Message, err error) {
+	m.failed.Add(int64(len(msgs)))
+	for _, msg := range msgs {
+		msg.Failed(err)
+	}
+}
+
+func (m *Mirror) Stats() MirrorStats {
+	stats := MirrorStats{
+		Mirrored: m.mirrored.Load(),
+		Filtered: m.filtered.Load(),
+		Failed:   m.failed.Load(),
+	}
+	if ns := m.lastMirroredAt.Load(); ns != 0 {
+		stats.LastMirroredAt = time.Unix(0, ns)
+	}
+	return stats
+}
+
+// Lag returns the events of the source subscription not mirrored yet.
+func (m *Mirror) Lag(ctx context.Context) (*SubscriptionLag, error) {
+	return m.source.Controller().Subscription().Lag(ctx, m.from)
+}
@@ -19,15 +19,19 @@ import (
 	"context"
 
 	// third-party libraries.
+	"google.golang.org/protobuf/types/known/emptypb"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 
 	// first-party libraries.
+	ctrlpb "github.com/vanus-labs/vanus/api/controller"
+	"github.com/vanus-labs/vanus/api/errors"
 	metapb "github.com/vanus-labs/vanus/api/meta"
 	proxypb "github.com/vanus-labs/vanus/api/proxy"
 )
 
 type namespace struct {
 	controller proxypb.ControllerProxyClient
+	cache      *metadataCache
 }
 
 // Make sure namespace implements Namespace.
@@ -40,3 +44,55 @@ func (ns *namespace) Get(ctx context.Context, name string) (*metapb.Namespace, e
 	}
 	return nsRef, nil
 }
+
+func (ns *namespace) List(ctx context.Context) ([]*metapb.Namespace, error) {
+	res, err := ns.controller.ListNamespace(ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	return res.GetNamespace(), nil
+}
+
+func (ns *namespace) Create(ctx context.Context, name, description string) (*metapb.Namespace, error) {
+	if name == "" {
+		return nil, ErrInvalidArguments
+	}
+	_, err := ns.get(ctx, name)
+	if err != ErrNamespaceNotFound {
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrNamespaceExist
+	}
+	return ns.controller.CreateNamespace(ctx, &ctrlpb.CreateNamespaceRequest{
+		Name:        name,
+		Description: description,
+	})
+}
+
+func (ns *namespace) Delete(ctx context.Context, name string) error {
+	nsRef, err := ns.get(ctx, name)
+	if err == ErrNamespaceNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = ns.controller.DeleteNamespace(ctx, &ctrlpb.DeleteNamespaceRequest{Id: nsRef.Id})
+	if err != nil {
+		return err
+	}
+	ns.cache.invalidate(cacheNamespace + name)
+	return nil
+}
+
+func (ns *namespace) get(ctx context.Context, name string) (*metapb.Namespace, error) {
+	nsRef, err := ns.controller.GetNamespaceWithHumanFriendly(ctx, wrapperspb.String(name))
+	if err != nil {
+		if errors.Is(err, errors.ErrResourceNotFound) {
+			return nil, ErrNamespaceNotFound
+		}
+		return nil, err
+	}
+	return nsRef, nil
+}
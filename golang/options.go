@@ -34,6 +34,7 @@ type EventOption func(opt *eventOptions)
 
 type eventOptions struct {
 	eventbusID uint64
+	eventlogID uint64
 	eventID    string
 	offset     int64
 	number     int32
@@ -52,27 +53,55 @@ func defaultEventOptions() eventOptions {
 	return eventOptions{}
 }
 
-func WithBatchEvents(eventbusID uint64, offset int64, number int32) EventOption {
+func WithBatchEvents(eventbusID ID, offset int64, number int32) EventOption {
 	return func(opt *eventOptions) {
-		opt.eventbusID = eventbusID
+		opt.eventbusID = uint64(eventbusID)
 		opt.offset = offset
 		opt.number = number
 	}
 }
 
-func WithEventID(eventbusID uint64, eventID string) EventOption {
+func WithEventID(eventbusID ID, eventID string) EventOption {
 	return func(opt *eventOptions) {
-		opt.eventbusID = eventbusID
+		opt.eventbusID = uint64(eventbusID)
 		opt.eventID = eventID
 	}
 }
 
+// WithEventlog reads events of the eventlog, e.g. the position of PublishResult.
+func WithEventlog(id ID) EventOption {
+	return func(opt *eventOptions) {
+		opt.eventlogID = uint64(id)
+	}
+}
+
 type EventbusOption func(opt *eventbusOptions)
 
 type eventbusOptions struct {
 	namespace    string
 	eventbusName string
 	eventbusID   uint64
+
+	// The properties of the eventbus, description is nil if it's unset.
+	logNumber   int
+	description *string
+	labels      map[string]string
+
+	quarantineNamespace string
+	quarantineEventbus  string
+
+	compression     string
+	compressMinSize int
+
+	keyProvider KeyProvider
+
+	blobStore           BlobStore
+	claimCheckThreshold int
+
+	partitionKeyExtension string
+	resolvePositions      bool
+	// eventlogs are resolved by the publisher for partitioned events.
+	eventlogs []uint64
 }
 
 func newEventbusOptions(options ...EventbusOption) eventbusOptions {
@@ -99,9 +128,91 @@ func WithEventbus(namespace, name string) EventbusOption {
 	}
 }
 
-func WithEventbusID(id uint64) EventbusOption {
+func WithEventbusID(id ID) EventbusOption {
 	return func(opt *eventbusOptions) {
-		opt.eventbusID = id
+		opt.eventbusID = uint64(id)
+	}
+}
+
+// WithLogNumber sets the number of eventlogs of a new eventbus, which is the
+// parallelism of writing and reading. It's 1 by default, and can't be changed
+// after the eventbus is created.
+func WithLogNumber(n int) EventbusOption {
+	return func(opt *eventbusOptions) {
+		opt.logNumber = n
+	}
+}
+
+// WithDescription sets the description of the eventbus.
+func WithDescription(description string) EventbusOption {
+	return func(opt *eventbusOptions) {
+		opt.description = &description
+	}
+}
+
+// WithLabels sets the labels of a new eventbus. CreateEventbusRequest of the
+// controller has no labels, so Create and Ensure return ErrLabelsUnsupported
+// with them, instead of dropping them.
+func WithLabels(labels map[string]string) EventbusOption {
+	return func(opt *eventbusOptions) {
+		opt.labels = labels
+	}
+}
+
+// WithPublishPositions resolves the eventlogs and offsets of published events
+// for PublishResult, by reading them back after publishing. The events carry
+// the publishreceipt extension to be told apart from others of the same ID. It
+// costs extra requests, and the events which aren't found soon are left
+// unresolved.
+func WithPublishPositions(is bool) EventbusOption {
+	return func(opt *eventbusOptions) {
+		opt.resolvePositions = is
+	}
+}
+
+// WithPartitionKeyExtension uses the value of the extension as the partition
+// key of events which have none, e.g. the ID of an entity set by producers.
+func WithPartitionKeyExtension(name string) EventbusOption {
+	return func(opt *eventbusOptions) {
+		opt.partitionKeyExtension = name
+	}
+}
+
+// WithQuarantine publishes events which don't match their schema to the
+// eventbus, instead of rejecting the whole batch. It takes effect when the
+// client has a SchemaRegistry.
+func WithQuarantine(namespace, name string) EventbusOption {
+	return func(opt *eventbusOptions) {
+		opt.quarantineNamespace = namespace
+		opt.quarantineEventbus = name
+	}
+}
+
+// WithPayloadCompression compresses the data of events which are not smaller
+// than minSize bytes by the registered PayloadCompressor, subscribers
+// decompress them transparently.
+func WithPayloadCompression(name string, minSize int) EventbusOption {
+	return func(opt *eventbusOptions) {
+		opt.compression = name
+		opt.compressMinSize = minSize
+	}
+}
+
+// WithEncryption encrypts the data of events by AES-GCM data keys, which are
+// wrapped by kp. Subscribers decrypt them by WithDecryption.
+func WithEncryption(kp KeyProvider) EventbusOption {
+	return func(opt *eventbusOptions) {
+		opt.keyProvider = kp
+	}
+}
+
+// WithClaimCheck stores the data of events larger than threshold bytes in the
+// store, and publishes the events with the reference of the data instead.
+// Subscribers load the data back by WithClaimCheckStore.
+func WithClaimCheck(store BlobStore, threshold int) EventbusOption {
+	return func(opt *eventbusOptions) {
+		opt.blobStore = store
+		opt.claimCheckThreshold = threshold
 	}
 }
 
@@ -109,6 +220,8 @@ type SubscriptionOption func(opt *subscriptionOptions)
 
 type subscriptionOptions struct {
 	subscriptionID         ID
+	namespace              string
+	subscriptionName       string
 	batchSize              int
 	port                   int
 	activeMode             bool
@@ -116,6 +229,13 @@ type subscriptionOptions struct {
 	order                  bool
 	parallelism            int
 	consumeTimeoutPerBatch time.Duration
+	keyProvider            KeyProvider
+	blobStore              BlobStore
+	blobCleanup            bool
+	lagGauge               *LagGauge
+	lagInterval            time.Duration
+	pushServer             *PushServer
+	selfRegistration       *selfRegistration
 }
 
 func newSubscriptionOptions(opts ...SubscriptionOption) subscriptionOptions {
@@ -127,7 +247,7 @@ func newSubscriptionOptions(opts ...SubscriptionOption) subscriptionOptions {
 }
 
 func (o subscriptionOptions) key() string {
-	return fmt.Sprintf("%d", o.subscriptionID)
+	return fmt.Sprintf("%s_%s_%d", o.namespace, o.subscriptionName, o.subscriptionID)
 }
 
 func defaultSubscribeOptions() subscriptionOptions {
@@ -145,6 +265,15 @@ func WithSubscriptionID(id ID) SubscriptionOption {
 	}
 }
 
+// WithSubscriptionName addresses the subscription by its name in the namespace,
+// which is resolved through the controller. WithSubscriptionID takes precedence.
+func WithSubscriptionName(namespace, name string) SubscriptionOption {
+	return func(opt *subscriptionOptions) {
+		opt.namespace = namespace
+		opt.subscriptionName = name
+	}
+}
+
 func WithMaxBatchSize(size int) SubscriptionOption {
 	return func(opt *subscriptionOptions) {
 		opt.batchSize = size
@@ -163,12 +292,23 @@ func WithListenPort(port int) SubscriptionOption {
 	}
 }
 
+// WithPushServer receives events pushed in passive mode by the push server,
+// instead of listening on the port of WithListenPort.
+func WithPushServer(ps *PushServer) SubscriptionOption {
+	return func(opt *subscriptionOptions) {
+		opt.pushServer = ps
+	}
+}
+
 func WithProtocol(p Protocol) SubscriptionOption {
 	return func(opt *subscriptionOptions) {
 		opt.protocol = p
 	}
 }
 
+// WithOrder handles messages of the same partition key in the order they're
+// received, one batch at a time, while messages of different keys are still
+// handled in parallel. See WithPartitionKey for the order of delivery.
 func WithOrder(is bool) SubscriptionOption {
 	return func(opt *subscriptionOptions) {
 		opt.order = is
@@ -186,3 +326,99 @@ func WithConsumeTimeout(t time.Duration) SubscriptionOption {
 		opt.consumeTimeoutPerBatch = t
 	}
 }
+
+// WithDecryption decrypts the data of encrypted events by kp before they're
+// passed to the handler.
+func WithDecryption(kp KeyProvider) SubscriptionOption {
+	return func(opt *subscriptionOptions) {
+		opt.keyProvider = kp
+	}
+}
+
+// WithClaimCheckStore loads the data of claim-checked events from the store
+// before they're passed to the handler, the data is deleted from the store after
+// Message.Success if cleanup is true.
+func WithClaimCheckStore(store BlobStore, cleanup bool) SubscriptionOption {
+	return func(opt *subscriptionOptions) {
+		opt.blobStore = store
+		opt.blobCleanup = cleanup
+	}
+}
+
+// WithLagGauge updates g while the subscriber is listening, the lag of events
+// is refreshed every interval.
+func WithLagGauge(g *LagGauge, interval time.Duration) SubscriptionOption {
+	return func(opt *subscriptionOptions) {
+		opt.lagGauge = g
+		opt.lagInterval = interval
+	}
+}
+
+type TypedOption func(opt *typedOptions)
+
+type typedOptions struct {
+	codec      Codec
+	codecErr   error
+	id         string
+	source     string
+	eventType  string
+	subject    string
+	extensions map[string]interface{}
+}
+
+func newTypedOptions(opts ...TypedOption) typedOptions {
+	o := typedOptions{}
+	for _, apply := range opts {
+		apply(&o)
+	}
+	return o
+}
+
+// WithCodec sets the codec used to encode the data, the default is
+// ProtobufCodec for proto messages and JSONCodec for others.
+func WithCodec(c Codec) TypedOption {
+	return func(opt *typedOptions) {
+		opt.codec, opt.codecErr = c, nil
+	}
+}
+
+// WithContentType encodes the data with the codec registered for contentType,
+// NewTypedEvent returns ErrCodecNotFound if there is none.
+func WithContentType(contentType string) TypedOption {
+	return func(opt *typedOptions) {
+		opt.codec, opt.codecErr = LookupCodec(contentType, "")
+	}
+}
+
+func WithEventSource(source string) TypedOption {
+	return func(opt *typedOptions) {
+		opt.source = source
+	}
+}
+
+func WithEventType(t string) TypedOption {
+	return func(opt *typedOptions) {
+		opt.eventType = t
+	}
+}
+
+func WithEventSubject(subject string) TypedOption {
+	return func(opt *typedOptions) {
+		opt.subject = subject
+	}
+}
+
+func WithTypedEventID(id string) TypedOption {
+	return func(opt *typedOptions) {
+		opt.id = id
+	}
+}
+
+func WithExtension(name string, value interface{}) TypedOption {
+	return func(opt *typedOptions) {
+		if opt.extensions == nil {
+			opt.extensions = map[string]interface{}{}
+		}
+		opt.extensions[name] = value
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vanus
+
+import (
+	// standard libraries.
+	"fmt"
+	"hash/fnv"
+	"sort"
+
+	// third-party libraries.
+	v2 "github.com/cloudevents/sdk-go/v2"
+)
+
+const (
+	// extPartitionKey is the key of the entity which the event belongs to.
+	extPartitionKey = "partitionkey"
+	// extPartitionLog is the eventlog which the partition key is hashed to. The
+	// proxy doesn't take the eventlog of published events, so it's only metadata
+	// and the event is stored in any eventlog of the eventbus.
+	extPartitionLog = "partitionlog"
+)
+
+// WithPartitionKey sets the partition key of the event. Subscribers with
+// WithOrder handle the events of the same key one batch at a time, in the order
+// they're received. The key is carried as an extension only: events aren't
+// routed to eventlogs by it, so the eventbus doesn't keep the events of a key in
+// order unless it has a single eventlog.
+func WithPartitionKey(key string) TypedOption {
+	return WithExtension(extPartitionKey, key)
+}
+
+// SetPartitionKey sets the partition key of e, see WithPartitionKey.
+func SetPartitionKey(e *v2.Event, key string) {
+	e.SetExtension(extPartitionKey, key)
+}
+
+// PartitionKey returns the partition key of e, it's empty if e has no key.
+func PartitionKey(e *v2.Event) string {
+	key, _ := e.Extensions()[extPartitionKey].(string)
+	return key
+}
+
+// partitionEvent returns a copy of e which carries the partition key and the
+// eventlog it's hashed to, e is returned if it has no key.
+func partitionEvent(e *v2.Event, keyExtension string, eventlogs []uint64) *v2.Event {
+	key := PartitionKey(e)
+	if key == "" && keyExtension != "" {
+		if v, ok := e.Extensions()[keyExtension]; ok {
+			key = fmt.Sprint(v)
+		}
+	}
+	if key == "" {
+		return e
+	}
+	out := e.Clone()
+	out.SetExtension(extPartitionKey, key)
+	if len(eventlogs) != 0 {
+		out.SetExtension(extPartitionLog, NewID(pickEventlog(key, eventlogs)).Hex())
+	}
+	return &out
+}
+
+// pickEventlog hashes key to one of the eventlogs by jump consistent hash, so
+// only 1/n of the keys are moved when the nth eventlog is added, as the IDs of
+// new eventlogs are larger.
+func pickEventlog(key string, eventlogs []uint64) uint64 {
+	sorted := make([]uint64, len(eventlogs))
+	copy(sorted, eventlogs)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[jumpHash(hashKey(key), len(sorted))]
+}
+
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return h.Sum64()
+}
+
+// jumpHash is the jump consistent hash of Lamping and Veach.
+func jumpHash(key uint64, buckets int) int {
+	var b, j int64 = -1, 0
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
@@ -0,0 +1,152 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vanus
+
+import (
+	// standard libraries.
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync/atomic"
+
+	// third-party libraries.
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/balancer/roundrobin"
+	_ "google.golang.org/grpc/health" // enables client-side health checking.
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+)
+
+const (
+	// LoadBalancingRoundRobin sends requests to healthy endpoints in turn.
+	LoadBalancingRoundRobin = roundrobin.Name
+	// LoadBalancingLeastRequest sends requests to the healthy endpoint with
+	// the fewest requests in flight.
+	LoadBalancingLeastRequest = "vanus_least_request"
+
+	endpointsScheme = "vanus-endpoints"
+)
+
+func init() {
+	balancer.Register(base.NewBalancerBuilder(LoadBalancingLeastRequest,
+		&leastRequestPickerBuilder{}, base.Config{HealthCheck: true}))
+}
+
+// endpointsOf returns the endpoints of options, Endpoint and Endpoints may
+// both be comma-separated lists.
+func endpointsOf(options *ClientOptions) []string {
+	var endpoints []string
+	for _, list := range append([]string{options.Endpoint}, options.Endpoints...) {
+		for _, endpoint := range strings.Split(list, ",") {
+			if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+				endpoints = append(endpoints, endpoint)
+			}
+		}
+	}
+	return endpoints
+}
+
+// serviceConfig returns the gRPC service config of the balancing policy, with
+// health checking of the endpoints if it's enabled. It's disabled by default,
+// since gRPC logs errors for servers without the health service.
+func serviceConfig(options *ClientOptions) (string, error) {
+	policy := options.LoadBalancing
+	if policy == "" {
+		policy = LoadBalancingRoundRobin
+	}
+	if balancer.Get(policy) == nil {
+		return "", fmt.Errorf("%w: unknown load balancing policy %s", ErrInvalidArguments, policy)
+	}
+	if !options.HealthCheck {
+		return fmt.Sprintf(`{"loadBalancingConfig": [{%q: {}}]}`, policy), nil
+	}
+	return fmt.Sprintf(`{"loadBalancingConfig": [{%q: {}}], "healthCheckConfig": {"serviceName": %q}}`,
+		policy, options.HealthCheckService), nil
+}
+
+// dialPool dials the connections of the pool, every one of which balances
+// requests among all endpoints. A single endpoint is dialed as the target, so
+// "dns:///host:port" balances among all addresses of the host.
+func dialPool(endpoints []string, size int, opts ...grpc.DialOption) ([]*grpc.ClientConn, error) {
+	if size <= 0 {
+		size = 1
+	}
+	pool := make([]*grpc.ClientConn, 0, size)
+	for idx := 0; idx < size; idx++ {
+		target, dialOpts := endpoints[0], opts
+		if len(endpoints) > 1 {
+			// A manual resolver can't be shared by connections.
+			r := manual.NewBuilderWithScheme(endpointsScheme)
+			addrs := make([]resolver.Address, 0, len(endpoints))
+			for _, endpoint := range endpoints {
+				addrs = append(addrs, resolver.Address{Addr: endpoint})
+			}
+			r.InitialState(resolver.State{Addresses: addrs})
+			target = r.Scheme() + ":///" + strings.Join(endpoints, ",")
+			dialOpts = append(dialOpts[:len(dialOpts):len(dialOpts)], grpc.WithResolvers(r))
+		}
+		conn, err := grpc.Dial(target, dialOpts...)
+		if err != nil {
+			for _, c := range pool {
+				_ = c.Close()
+			}
+			return nil, err
+		}
+		pool = append(pool, conn)
+	}
+	return pool, nil
+}
+
+type leastRequestPickerBuilder struct{}
+
+func (*leastRequestPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+	p := &leastRequestPicker{}
+	for sc := range info.ReadySCs {
+		p.subConns = append(p.subConns, sc)
+	}
+	p.inflight = make([]int64, len(p.subConns))
+	return p
+}
+
+// leastRequestPicker picks the ready sub-connection with the fewest requests
+// in flight, ties are broken from a random start.
+type leastRequestPicker struct {
+	subConns []balancer.SubConn
+	inflight []int64
+}
+
+func (p *leastRequestPicker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	n := len(p.subConns)
+	start := rand.Intn(n) //nolint:gosec // not for security.
+	picked := start
+	for idx := 1; idx < n; idx++ {
+		i := (start + idx) % n
+		if atomic.LoadInt64(&p.inflight[i]) < atomic.LoadInt64(&p.inflight[picked]) {
+			picked = i
+		}
+	}
+	atomic.AddInt64(&p.inflight[picked], 1)
+	return balancer.PickResult{
+		SubConn: p.subConns[picked],
+		Done: func(balancer.DoneInfo) {
+			atomic.AddInt64(&p.inflight[picked], -1)
+		},
+	}, nil
+}
@@ -16,9 +16,13 @@ package vanus
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
+	"time"
 
 	v2 "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
 	"google.golang.org/grpc"
 
 	"github.com/vanus-labs/vanus/api/cloudevents"
@@ -26,18 +30,30 @@ import (
 )
 
 type publisher struct {
-	store    proxypb.StoreProxyClient
-	options  eventbusOptions
-	idSetter func(ctx context.Context, opt *eventbusOptions) error
-	mutex    sync.Mutex
+	store      proxypb.StoreProxyClient
+	controller proxypb.ControllerProxyClient
+	options    eventbusOptions
+	idSetter   func(ctx context.Context, opt *eventbusOptions) error
+	mutex      sync.Mutex
+	registry   SchemaRegistry
+	quarantine Publisher
+	cache      *metadataCache
+	// named is whether the eventbus is addressed by name, so it's resolved
+	// again if it's recreated.
+	named bool
 }
 
 func newPublisher(cc *grpc.ClientConn, idSetter func(ctx context.Context, opt *eventbusOptions) error,
-	opts eventbusOptions) Publisher {
+	opts eventbusOptions, registry SchemaRegistry, quarantine Publisher, cache *metadataCache) Publisher {
 	return &publisher{
-		store:    proxypb.NewStoreProxyClient(cc),
-		options:  opts,
-		idSetter: idSetter,
+		store:      proxypb.NewStoreProxyClient(cc),
+		controller: proxypb.NewControllerProxyClient(cc),
+		options:    opts,
+		idSetter:   idSetter,
+		registry:   registry,
+		quarantine: quarantine,
+		cache:      cache,
+		named:      opts.eventbusID == 0 && opts.eventbusName != "",
 	}
 }
 
@@ -55,31 +71,230 @@ func (p *publisher) Eventbus() string {
 }
 
 func (p *publisher) Publish(ctx context.Context, events ...*v2.Event) error {
-	pbs := make([]*cloudevents.CloudEvent, 0, len(events))
-	for idx := range events {
-		pb, err := ToProto(events[idx])
-		if err != nil {
-			return err
+	_, err := p.publish(ctx, events, "")
+	return err
+}
+
+func (p *publisher) PublishWithResults(ctx context.Context, events ...*v2.Event) ([]PublishResult, error) {
+	start := time.Now()
+	var receipt string
+	if p.options.resolvePositions {
+		receipt = uuid.NewString()
+	}
+	quarantined, err := p.publish(ctx, events, receipt)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	eventbusID := p.eventbusID()
+	results := make([]PublishResult, len(events))
+	published := 0
+	for idx, e := range events {
+		results[idx] = PublishResult{
+			EventID: e.ID(),
+			Status:  PublishStatusPublished,
+			Offset:  UnknownOffset,
+			Time:    now,
+		}
+		if quarantined[idx] {
+			results[idx].Status = PublishStatusQuarantined
+		} else {
+			results[idx].EventbusID = NewID(eventbusID)
+			published++
+		}
+	}
+	if receipt != "" && published != 0 {
+		if err = p.resolvePositions(ctx, eventbusID, start, receipt, results); err != nil {
+			return results, err
 		}
-		pbs = append(pbs, pb)
 	}
+	return results, nil
+}
 
-	if p.options.eventbusID == 0 {
-		p.mutex.Lock()
-		err := p.idSetter(ctx, &p.options)
-		p.mutex.Unlock()
+// publish publishes events, and returns whether each of them is quarantined
+// instead because it doesn't match its schema. The published events carry the
+// receipt and their index if receipt isn't empty.
+func (p *publisher) publish(ctx context.Context, events []*v2.Event, receipt string) ([]bool, error) {
+	quarantined := make([]bool, len(events))
+	valid := events
+	if p.registry != nil {
+		var err error
+		if valid, err = p.validate(ctx, events, quarantined); err != nil {
+			return nil, err
+		}
+		if len(valid) == 0 {
+			return quarantined, nil
+		}
+	}
+	if receipt != "" {
+		valid = withReceipts(events, quarantined, receipt)
+	}
+
+	prepared, refs, err := p.prepare(ctx, valid)
+	if err != nil {
+		return nil, err
+	}
+	err = p.send(ctx, prepared)
+	if isNotFound(err) && p.named {
+		// The eventbus may be deleted and recreated with the same name.
+		if err = p.reresolve(ctx); err == nil {
+			err = p.send(ctx, prepared)
+		}
+	}
+	if err != nil {
+		p.discard(refs)
+		return nil, err
+	}
+	return quarantined, nil
+}
+
+// prepare compresses, encrypts and checks in the data of events, once for all
+// attempts of sending them. It returns the references of the checked in data.
+func (p *publisher) prepare(ctx context.Context, events []*v2.Event) ([]*v2.Event, []string, error) {
+	var compressor PayloadCompressor
+	if p.options.compression != "" {
+		var err error
+		if compressor, err = lookupPayloadCompressor(p.options.compression); err != nil {
+			return nil, nil, err
+		}
+	}
+	prepared := make([]*v2.Event, 0, len(events))
+	var refs []string
+	for _, e := range events {
+		var err error
+		if compressor != nil {
+			if e, err = compressEvent(e, compressor, p.options.compressMinSize); err != nil {
+				p.discard(refs)
+				return nil, nil, err
+			}
+		}
+		if p.options.keyProvider != nil {
+			if e, err = encryptEvent(ctx, e, p.options.keyProvider); err != nil {
+				p.discard(refs)
+				return nil, nil, err
+			}
+		}
+		if p.options.blobStore != nil {
+			checkedIn, err := checkInEvent(ctx, e, p.options.blobStore, p.Eventbus(), p.options.claimCheckThreshold)
+			if err != nil {
+				p.discard(refs)
+				return nil, nil, err
+			}
+			if checkedIn != e {
+				refs = append(refs, claimCheckRef(checkedIn))
+			}
+			e = checkedIn
+		}
+		prepared = append(prepared, e)
+	}
+	return prepared, refs, nil
+}
+
+// discard deletes the checked in data of events which aren't published.
+func (p *publisher) discard(refs []string) {
+	for _, ref := range refs {
+		// TODO(wenfeng) log
+		_ = p.options.blobStore.Delete(context.Background(), ref)
+	}
+}
+
+// reresolve drops the cached eventbus and resolves its name again.
+func (p *publisher) reresolve(ctx context.Context) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.cache.invalidate(eventbusCacheKey(p.options.namespace, p.options.eventbusName, 0))
+	p.options.eventbusID = 0
+	p.options.eventlogs = nil
+	return p.idSetter(ctx, &p.options)
+}
+
+func (p *publisher) send(ctx context.Context, events []*v2.Event) error {
+	eventbusID, eventlogs, err := p.resolve(ctx, events)
+	if err != nil {
+		return err
+	}
+
+	pbs := make([]*cloudevents.CloudEvent, 0, len(events))
+	for idx := range events {
+		e := partitionEvent(events[idx], p.options.partitionKeyExtension, eventlogs)
+		pb, err := ToProto(e)
 		if err != nil {
 			return err
 		}
+		pbs = append(pbs, pb)
 	}
 
 	in := &proxypb.PublishRequest{
-		EventbusId: p.options.eventbusID,
+		EventbusId: eventbusID,
 		Events: &cloudevents.CloudEventBatch{
 			Events: pbs,
 		},
 	}
 
-	_, err := p.store.Publish(ctx, in)
+	_, err = p.store.Publish(ctx, in)
 	return err
 }
+
+// eventbusID returns the ID of the eventbus, it's 0 if it isn't resolved.
+func (p *publisher) eventbusID() uint64 {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.options.eventbusID
+}
+
+// resolve returns the eventbus and its eventlogs, which are resolved first if
+// they're unknown. They're changed by reresolve concurrently, so they're read
+// under the lock.
+func (p *publisher) resolve(ctx context.Context, events []*v2.Event) (uint64, []uint64, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.options.eventbusID == 0 || (p.options.eventlogs == nil && p.partitioned(events)) {
+		if err := p.idSetter(ctx, &p.options); err != nil {
+			return 0, nil, err
+		}
+	}
+	return p.options.eventbusID, p.options.eventlogs, nil
+}
+
+// partitioned returns whether any of events has a partition key.
+func (p *publisher) partitioned(events []*v2.Event) bool {
+	for _, e := range events {
+		if PartitionKey(e) != "" {
+			return true
+		}
+		if _, ok := e.Extensions()[p.options.partitionKeyExtension]; ok && p.options.partitionKeyExtension != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// validate returns the events which match their schemas, the others are
+// published to the quarantine eventbus if it's set and marked in quarantined,
+// or fail the whole batch.
+func (p *publisher) validate(ctx context.Context, events []*v2.Event, quarantined []bool) ([]*v2.Event, error) {
+	valid := make([]*v2.Event, 0, len(events))
+	var invalid []*v2.Event
+	for idx, e := range events {
+		err := validateEvent(ctx, p.registry, e)
+		if err == nil {
+			valid = append(valid, e)
+			continue
+		}
+		// Only mismatched events are quarantined, failing to resolve their
+		// schemas fails the whole batch, e.g. if the registry is unreachable.
+		if p.quarantine == nil || !errors.Is(err, ErrSchemaValidation) {
+			return nil, fmt.Errorf("event %s: %w", e.ID(), err)
+		}
+		q := e.Clone()
+		q.SetExtension(extSchemaError, err.Error())
+		invalid = append(invalid, &q)
+		quarantined[idx] = true
+	}
+	if len(invalid) != 0 {
+		if err := p.quarantine.Publish(ctx, invalid...); err != nil {
+			return nil, err
+		}
+	}
+	return valid, nil
+}
@@ -0,0 +1,269 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vanus
+
+import (
+	// standard libraries.
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"sync"
+
+	// third-party libraries.
+	"github.com/cloudevents/sdk-go/v2/binding"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	// first-party libraries.
+	"github.com/vanus-labs/vanus/api/cloudevents"
+)
+
+// SubscriptionIDHeader is the gRPC metadata or HTTP header of pushed events,
+// which routes them to the subscriber of the subscription ID in hex.
+const SubscriptionIDHeader = "x-vanus-subscription-id"
+
+// PushServer serves the subscribers in passive mode on a server of the
+// caller, so they share its port, interceptors and TLS:
+//
+//	ps := vanus.NewPushServer()
+//	ps.RegisterGRPC(srv)          // a *grpc.Server
+//	mux.Handle("/events/", ps)    // an *http.ServeMux
+//	sub := c.Subscriber(vanus.WithSubscriptionID(id), vanus.WithPushServer(ps))
+//	err := sub.Listen(handler)
+//
+// Pushed events are routed by SubscriptionIDHeader. Without it, HTTP requests
+// are routed by the last element of the URL path, and gRPC requests by the
+// eventbus, if only one subscriber of it is registered, they're rejected if
+// there is none. Requests which carry neither are routed to the only
+// subscriber if there is one.
+type PushServer struct {
+	mu          sync.RWMutex
+	subscribers map[ID]*pushSubscriber
+}
+
+type pushSubscriber struct {
+	s          *subscribe
+	eventbusID ID
+}
+
+func NewPushServer() *PushServer {
+	return &PushServer{subscribers: map[ID]*pushSubscriber{}}
+}
+
+// RegisterGRPC registers the CloudEvents service of pushed events on srv,
+// which must be done before srv serves.
+func (ps *PushServer) RegisterGRPC(srv grpc.ServiceRegistrar) {
+	cloudevents.RegisterCloudEventsServer(srv, ps)
+}
+
+// Send implements cloudevents.CloudEventsServer.
+func (ps *PushServer) Send(ctx context.Context, event *cloudevents.BatchEvent) (*emptypb.Empty, error) {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(SubscriptionIDHeader); len(values) != 0 {
+			id = values[0]
+		}
+	}
+	s, err := ps.route(id, NewID(event.GetEventbusId()))
+	if err != nil {
+		return nil, err
+	}
+	if err = s.deliver(ctx, event.GetEvents()); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// ServeHTTP handles events pushed in binary, structured or batched CloudEvents
+// encoding, and responds 200 after the subscriber acknowledges all of them.
+func (ps *PushServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := r.Header.Get(SubscriptionIDHeader)
+	if id == "" {
+		// The path may be only the prefix the server is mounted on.
+		if _, err := ParseID(path.Base(r.URL.Path)); err == nil {
+			id = path.Base(r.URL.Path)
+		}
+	}
+	s, err := ps.route(id, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	serveHTTP(w, r, s)
+}
+
+// Handler returns the handler of events pushed to the subscription id over
+// HTTP, e.g. to mount subscribers on paths of their own.
+func (ps *PushServer) Handler(id ID) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, err := ps.route(id.Hex(), 0)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		serveHTTP(w, r, s)
+	})
+}
+
+func serveHTTP(w http.ResponseWriter, r *http.Request, s *subscribe) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	batch, err := batchFromHTTPRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err = s.deliver(r.Context(), batch); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func batchFromHTTPRequest(r *http.Request) (*cloudevents.CloudEventBatch, error) {
+	msg := cehttp.NewMessageFromHttpRequest(r)
+	defer func() {
+		_ = msg.Finish(nil)
+	}()
+	batch := &cloudevents.CloudEventBatch{}
+	if msg.ReadEncoding() == binding.EncodingBatch {
+		events, err := binding.ToEvents(r.Context(), msg, msg.BodyReader)
+		if err != nil {
+			return nil, err
+		}
+		for idx := range events {
+			e, err := ToProto(&events[idx])
+			if err != nil {
+				return nil, err
+			}
+			batch.Events = append(batch.Events, e)
+		}
+		return batch, nil
+	}
+	event, err := binding.ToEvent(r.Context(), msg)
+	if err != nil {
+		return nil, err
+	}
+	e, err := ToProto(event)
+	if err != nil {
+		return nil, err
+	}
+	batch.Events = append(batch.Events, e)
+	return batch, nil
+}
+
+// route returns the subscriber of the subscription id. If id is empty, it's the
+// only one of the eventbus, or the only one of the server if the eventbus is
+// unknown.
+func (ps *PushServer) route(id string, eventbusID ID) (*subscribe, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	if id != "" {
+		subscriptionID, err := ParseID(id)
+		if err != nil {
+			return nil, fmt.Errorf("%w: subscription id %q", ErrInvalidArguments, id)
+		}
+		if p := ps.subscribers[subscriptionID]; p != nil {
+			return p.s, nil
+		}
+		return nil, fmt.Errorf("%w: %s isn't registered", ErrSubscriptionNotFound, subscriptionID)
+	}
+	var found *subscribe
+	if eventbusID != 0 {
+		for _, p := range ps.subscribers {
+			if p.eventbusID != eventbusID {
+				continue
+			}
+			if found != nil {
+				return nil, fmt.Errorf("%w: %s is subscribed more than once, set %s",
+					ErrInvalidArguments, eventbusID, SubscriptionIDHeader)
+			}
+			found = p.s
+		}
+		if found != nil {
+			return found, nil
+		}
+		return nil, fmt.Errorf("%w: no subscriber of %s is registered, set %s",
+			ErrSubscriptionNotFound, eventbusID, SubscriptionIDHeader)
+	}
+	if len(ps.subscribers) == 1 {
+		for _, p := range ps.subscribers {
+			return p.s, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: set %s", ErrSubscriptionNotFound, SubscriptionIDHeader)
+}
+
+// serve registers the subscriber until it's closed.
+func (ps *PushServer) serve(s *subscribe) error {
+	id := s.SubscriptionID()
+	if id == 0 {
+		return ErrSubscriptionIDIsZero
+	}
+	registered := &pushSubscriber{s: s}
+	// The eventbus only routes events without SubscriptionIDHeader, so it's
+	// best effort.
+	if sub, err := s.controller.Get(context.Background(), WithSubscriptionID(id)); err == nil {
+		registered.eventbusID = NewID(sub.GetEventbusId())
+	}
+
+	ps.mu.Lock()
+	if _, ok := ps.subscribers[id]; ok {
+		ps.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrSubscriptionRegistered, id)
+	}
+	ps.subscribers[id] = registered
+	ps.mu.Unlock()
+
+	<-s.closeC
+	ps.mu.Lock()
+	delete(ps.subscribers, id)
+	ps.mu.Unlock()
+	return nil
+}
+
+// deliver processes the pushed batch, and returns after all of its messages
+// are acknowledged, or the first of them fails.
+func (s *subscribe) deliver(ctx context.Context, batch *cloudevents.CloudEventBatch) error {
+	if len(batch.GetEvents()) == 0 {
+		return nil
+	}
+	select {
+	case <-s.closeC:
+		return ErrSubscriberClosed
+	default:
+	}
+	ch := make(chan error, 1)
+	s.processCloudEvents(batch, func(err error) {
+		// Every failed message calls back, only the first one is returned.
+		select {
+		case ch <- err:
+		default:
+		}
+	})
+	select {
+	case err := <-ch:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.closeC:
+		return ErrSubscriberClosed
+	}
+}
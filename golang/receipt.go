@@ -0,0 +1,173 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vanus
+
+import (
+	// standard libraries.
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	// third-party libraries.
+	v2 "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/types"
+
+	// first-party libraries.
+	proxypb "github.com/vanus-labs/vanus/api/proxy"
+)
+
+const (
+	// positionLookback is subtracted from the publishing time when the offsets
+	// are looked up, to tolerate the clock skew between the client and servers.
+	positionLookback = time.Second
+	// maxPositionPages is the number of pages read per eventlog to find the
+	// published events.
+	maxPositionPages = 16
+	// UnknownOffset is the offset of events whose positions aren't resolved.
+	UnknownOffset = int64(-1)
+	// extPublishReceipt identifies an event of a publishing to resolve its
+	// position, it's the receipt of the publishing and the index of the event.
+	extPublishReceipt = "publishreceipt"
+)
+
+type PublishStatus string
+
+const (
+	// PublishStatusPublished is the status of events published to the eventbus.
+	PublishStatusPublished PublishStatus = "published"
+	// PublishStatusQuarantined is the status of events which don't match their
+	// schemas, and are published to the quarantine eventbus, see WithQuarantine.
+	PublishStatusQuarantined PublishStatus = "quarantined"
+)
+
+// PublishResult is the receipt of a published event.
+type PublishResult struct {
+	EventID string        `json:"event_id"`
+	Status  PublishStatus `json:"status"`
+	// EventbusID is 0 if the event is quarantined.
+	EventbusID ID `json:"eventbus_id"`
+	// EventlogID and Offset are the position of the event, which are resolved
+	// with WithPublishPositions. Offset is UnknownOffset otherwise.
+	EventlogID ID    `json:"eventlog_id,omitempty"`
+	Offset     int64 `json:"offset"`
+	// Time is the time when the event is stored if it's resolved, or when the
+	// publishing is acknowledged.
+	Time time.Time `json:"time"`
+}
+
+// Resolved returns whether the position of the event is resolved.
+func (r PublishResult) Resolved() bool {
+	return r.Offset != UnknownOffset
+}
+
+// PublishFuture is the result of PublishAsync.
+type PublishFuture struct {
+	done    chan struct{}
+	results []PublishResult
+	err     error
+}
+
+// Done is closed when the publishing completes.
+func (f *PublishFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+// Results waits for the publishing and returns its results.
+func (f *PublishFuture) Results() ([]PublishResult, error) {
+	<-f.done
+	return f.results, f.err
+}
+
+func (p *publisher) PublishAsync(ctx context.Context, events ...*v2.Event) *PublishFuture {
+	f := &PublishFuture{done: make(chan struct{})}
+	go func() {
+		defer close(f.done)
+		f.results, f.err = p.PublishWithResults(ctx, events...)
+	}()
+	return f
+}
+
+// withReceipts returns copies of the events which aren't quarantined, carrying
+// the receipt and their indexes.
+func withReceipts(events []*v2.Event, quarantined []bool, receipt string) []*v2.Event {
+	out := make([]*v2.Event, 0, len(events))
+	for idx, e := range events {
+		if quarantined[idx] {
+			continue
+		}
+		c := e.Clone()
+		c.SetExtension(extPublishReceipt, receipt+"."+strconv.Itoa(idx))
+		out = append(out, &c)
+	}
+	return out
+}
+
+// resolvePositions finds the events published with the receipt by reading the
+// eventlogs of the eventbus from the offsets at since, the unresolved results
+// are left untouched.
+func (p *publisher) resolvePositions(ctx context.Context, eventbusID uint64, since time.Time,
+	receipt string, results []PublishResult) error {
+	pending := make(map[string]int, len(results))
+	for idx := range results {
+		if results[idx].Status == PublishStatusPublished {
+			pending[receipt+"."+strconv.Itoa(idx)] = idx
+		}
+	}
+	offsets, err := p.controller.LookupOffset(ctx, &proxypb.LookupOffsetRequest{
+		EventbusId: eventbusID,
+		Timestamp:  since.Add(-positionLookback).UnixMilli(),
+	})
+	if err != nil {
+		return err
+	}
+	for logID, offset := range offsets.Offsets {
+		for page := 0; page < maxPositionPages && len(pending) != 0 && offset >= 0; page++ {
+			res, err := p.controller.GetEvent(ctx, &proxypb.GetEventRequest{
+				EventbusId: eventbusID,
+				EventlogId: logID,
+				Offset:     offset,
+				Number:     maximumNumberPerGetRequest,
+			})
+			if err != nil {
+				return err
+			}
+			for idx, raw := range res.Events {
+				e := v2.NewEvent()
+				if json.Unmarshal(raw.Value, &e) != nil {
+					continue
+				}
+				key, _ := e.Extensions()[extPublishReceipt].(string)
+				i, ok := pending[key]
+				if !ok {
+					continue
+				}
+				delete(pending, key)
+				results[i].EventlogID = NewID(logID)
+				results[i].Offset = offset + int64(idx)
+				if v, ok := e.Extensions()[extStoredTime]; ok {
+					if t, err := types.ToTime(v); err == nil {
+						results[i].Time = t
+					}
+				}
+			}
+			if len(res.Events) < maximumNumberPerGetRequest {
+				break
+			}
+			offset += int64(len(res.Events))
+		}
+	}
+	return nil
+}
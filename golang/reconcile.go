@@ -0,0 +1,542 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vanus
+
+import (
+	// standard libraries.
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	// third-party libraries.
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"sigs.k8s.io/yaml"
+
+	// first-party libraries.
+	ctrlpb "github.com/vanus-labs/vanus/api/controller"
+	metapb "github.com/vanus-labs/vanus/api/meta"
+)
+
+const (
+	KindNamespace    = "namespace"
+	KindEventbus     = "eventbus"
+	KindSubscription = "subscription"
+
+	// Resources with the prefix are managed by Vanus itself, they are never pruned.
+	systemResourcePrefix = "__"
+	systemNamespace      = "vanus"
+)
+
+// DesiredState is the declarative document of Vanus resources, e.g.
+//
+//	namespaces:
+//	  - name: orders
+//	eventbuses:
+//	  - namespace: orders
+//	    name: created
+//	subscriptions:
+//	  - namespace: orders
+//	    eventbus: created
+//	    name: billing
+//	    sink: http://billing.svc/events
+//	    filters: [{exact: {type: order.created}}]
+//
+// The fields of subscriptions other than namespace and eventbus are the fields
+// of SubscriptionRequest in protojson.
+type DesiredState struct {
+	Namespaces    []NamespaceSpec    `json:"namespaces,omitempty"`
+	Eventbuses    []EventbusSpec     `json:"eventbuses,omitempty"`
+	Subscriptions []SubscriptionSpec `json:"subscriptions,omitempty"`
+}
+
+// NamespaceSpec is a namespace which is created if it doesn't exist. The
+// controller can't update namespaces, so the description of an existing one is
+// left as it is, and namespaces are never pruned.
+type NamespaceSpec struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// EventbusSpec is an eventbus which is created if it doesn't exist. The
+// controller can't update eventbuses, so the log number and description of an
+// existing one are left as they are.
+type EventbusSpec struct {
+	Namespace   string `json:"namespace"`
+	Name        string `json:"name"`
+	LogNumber   int    `json:"logNumber,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+func (s EventbusSpec) options() []EventbusOption {
+	opts := []EventbusOption{WithEventbus(s.Namespace, s.Name), WithLogNumber(s.LogNumber)}
+	if s.Description != "" {
+		opts = append(opts, WithDescription(s.Description))
+	}
+	return opts
+}
+
+type SubscriptionSpec struct {
+	Namespace string
+	Eventbus  string
+	Request   *ctrlpb.SubscriptionRequest
+}
+
+func (s *SubscriptionSpec) UnmarshalJSON(data []byte) error {
+	var ref struct {
+		Namespace string `json:"namespace"`
+		Eventbus  string `json:"eventbus"`
+	}
+	if err := json.Unmarshal(data, &ref); err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	delete(fields, "namespace")
+	delete(fields, "eventbus")
+	rest, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	req := &ctrlpb.SubscriptionRequest{}
+	if err = protojson.Unmarshal(rest, req); err != nil {
+		return fmt.Errorf("subscription %s/%s: %w", ref.Namespace, req.GetName(), err)
+	}
+	s.Namespace, s.Eventbus, s.Request = ref.Namespace, ref.Eventbus, req
+	return nil
+}
+
+func (s SubscriptionSpec) MarshalJSON() ([]byte, error) {
+	data, err := protojson.Marshal(s.Request)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]json.RawMessage{}
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	fields["namespace"], _ = json.Marshal(s.Namespace)
+	fields["eventbus"], _ = json.Marshal(s.Eventbus)
+	return json.Marshal(fields)
+}
+
+// LoadDesiredState parses the document in YAML or JSON.
+func LoadDesiredState(data []byte) (*DesiredState, error) {
+	data, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	ds := &DesiredState{}
+	if err = json.Unmarshal(data, ds); err != nil {
+		return nil, err
+	}
+	for _, s := range ds.Subscriptions {
+		if s.Namespace == "" || s.Eventbus == "" || s.Request.GetName() == "" {
+			return nil, fmt.Errorf("%w: subscription requires namespace, eventbus and name", ErrInvalidArguments)
+		}
+	}
+	return ds, nil
+}
+
+type ChangeAction string
+
+const (
+	ActionCreate ChangeAction = "create"
+	ActionUpdate ChangeAction = "update"
+	ActionDelete ChangeAction = "delete"
+)
+
+// Change is a planned change of a resource.
+type Change struct {
+	Action    ChangeAction `json:"action"`
+	Kind      string       `json:"kind"`
+	Namespace string       `json:"namespace,omitempty"`
+	Name      string       `json:"name"`
+	// Diff describes the fields to update.
+	Diff []string `json:"diff,omitempty"`
+
+	apply func(ctx context.Context) error
+}
+
+func (c Change) String() string {
+	sign := map[ChangeAction]string{ActionCreate: "+", ActionUpdate: "~", ActionDelete: "-"}[c.Action]
+	name := c.Name
+	if c.Namespace != "" {
+		name = c.Namespace + "/" + c.Name
+	}
+	s := fmt.Sprintf("%s %s %s", sign, c.Kind, name)
+	if len(c.Diff) != 0 {
+		s += " (" + strings.Join(c.Diff, ", ") + ")"
+	}
+	return s
+}
+
+// Plan is the changes to converge the cluster to a DesiredState, in the order
+// they are applied.
+type Plan struct {
+	Changes []Change `json:"changes"`
+	// Retained are resources which are absent from the desired state, but not
+	// deleted because pruning is disabled or they are protected.
+	Retained []Change `json:"retained,omitempty"`
+}
+
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+func (p *Plan) String() string {
+	if p.Empty() && len(p.Retained) == 0 {
+		return "No changes, the cluster is up to date.\n"
+	}
+	var b strings.Builder
+	for _, c := range p.Changes {
+		b.WriteString(c.String())
+		b.WriteByte('\n')
+	}
+	for _, c := range p.Retained {
+		fmt.Fprintf(&b, "! %s %s/%s is not in the desired state, retained\n", c.Kind, c.Namespace, c.Name)
+	}
+	var creates, updates, deletes int
+	for _, c := range p.Changes {
+		switch c.Action {
+		case ActionCreate:
+			creates++
+		case ActionUpdate:
+			updates++
+		case ActionDelete:
+			deletes++
+		}
+	}
+	fmt.Fprintf(&b, "Plan: %d to create, %d to update, %d to delete.\n", creates, updates, deletes)
+	return b.String()
+}
+
+type ReconcileOption func(opt *reconcileOptions)
+
+type reconcileOptions struct {
+	prune     bool
+	dryRun    bool
+	protected map[string]bool
+}
+
+// WithPrune deletes the eventbuses and subscriptions which are absent from the
+// desired state, in the namespaces of the desired state. Namespaces, resources
+// of other namespaces and system resources are never deleted.
+func WithPrune(prune bool) ReconcileOption {
+	return func(opt *reconcileOptions) {
+		opt.prune = prune
+	}
+}
+
+// WithDryRun only plans the changes.
+func WithDryRun(dryRun bool) ReconcileOption {
+	return func(opt *reconcileOptions) {
+		opt.dryRun = dryRun
+	}
+}
+
+// WithProtected protects resources from pruning, names are in the form of
+// "kind/namespace/name", e.g. "eventbus/orders/created".
+func WithProtected(names ...string) ReconcileOption {
+	return func(opt *reconcileOptions) {
+		for _, name := range names {
+			opt.protected[name] = true
+		}
+	}
+}
+
+// Reconciler converges Vanus resources to a DesiredState.
+type Reconciler struct {
+	controller Controller
+	opts       reconcileOptions
+}
+
+func NewReconciler(c Controller, opts ...ReconcileOption) *Reconciler {
+	o := reconcileOptions{protected: map[string]bool{}}
+	for _, apply := range opts {
+		apply(&o)
+	}
+	return &Reconciler{controller: c, opts: o}
+}
+
+// Reconcile plans the changes and applies them unless it's a dry run.
+func (r *Reconciler) Reconcile(ctx context.Context, desired *DesiredState) (*Plan, error) {
+	plan, err := r.Plan(ctx, desired)
+	if err != nil {
+		return nil, err
+	}
+	if r.opts.dryRun {
+		return plan, nil
+	}
+	return plan, r.Apply(ctx, plan)
+}
+
+// Apply applies the changes of plan in order, and stops at the first error.
+func (r *Reconciler) Apply(ctx context.Context, plan *Plan) error {
+	for _, c := range plan.Changes {
+		if err := c.apply(ctx); err != nil {
+			return fmt.Errorf("%s: %w", c, err)
+		}
+	}
+	return nil
+}
+
+// Plan diffs desired against the resources of the cluster.
+func (r *Reconciler) Plan(ctx context.Context, desired *DesiredState) (*Plan, error) {
+	nss, err := r.controller.Namespace().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ebs, err := r.controller.Eventbus().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	subs, err := r.controller.Subscription().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nsByName := map[string]*metapb.Namespace{}
+	nsByID := map[uint64]*metapb.Namespace{}
+	for _, ns := range nss {
+		nsByName[ns.Name] = ns
+		nsByID[ns.Id] = ns
+	}
+	type ebKey struct{ namespace, name string }
+	ebByName := map[ebKey]*metapb.Eventbus{}
+	ebByID := map[uint64]*metapb.Eventbus{}
+	for _, eb := range ebs {
+		if ns, ok := nsByID[eb.NamespaceId]; ok {
+			ebByName[ebKey{ns.Name, eb.Name}] = eb
+		}
+		ebByID[eb.Id] = eb
+	}
+	type subKey struct{ namespace, name string }
+	subByName := map[subKey]*metapb.Subscription{}
+	for _, sub := range subs {
+		if ns, ok := nsByID[sub.NamespaceId]; ok {
+			subByName[subKey{ns.Name, sub.Name}] = sub
+		}
+	}
+
+	// The scope of pruning.
+	managed := map[string]bool{}
+	plan := &Plan{}
+	var deletes []Change
+
+	wantNS := map[string]bool{}
+	for _, spec := range desired.Namespaces {
+		spec := spec
+		wantNS[spec.Name] = true
+		managed[spec.Name] = true
+		if _, ok := nsByName[spec.Name]; ok {
+			continue
+		}
+		plan.Changes = append(plan.Changes, Change{
+			Action: ActionCreate, Kind: KindNamespace, Name: spec.Name,
+			apply: func(ctx context.Context) error {
+				_, err := r.controller.Namespace().Create(ctx, spec.Name, spec.Description)
+				return err
+			},
+		})
+	}
+
+	wantEB := map[ebKey]bool{}
+	for _, spec := range desired.Eventbuses {
+		spec := spec
+		wantEB[ebKey{spec.Namespace, spec.Name}] = true
+		managed[spec.Namespace] = true
+		if _, ok := ebByName[ebKey{spec.Namespace, spec.Name}]; ok {
+			// The controller can't update eventbuses, see EventbusSpec.
+			continue
+		}
+		plan.Changes = append(plan.Changes, Change{
+			Action: ActionCreate, Kind: KindEventbus, Namespace: spec.Namespace, Name: spec.Name,
+			apply: func(ctx context.Context) error {
+				_, err := r.controller.Eventbus().Create(ctx, spec.options()...)
+				return err
+			},
+		})
+	}
+
+	wantSub := map[subKey]bool{}
+	for _, spec := range desired.Subscriptions {
+		spec := spec
+		name := spec.Request.GetName()
+		wantSub[subKey{spec.Namespace, name}] = true
+		managed[spec.Namespace] = true
+		existing, ok := subByName[subKey{spec.Namespace, name}]
+		if !ok {
+			plan.Changes = append(plan.Changes, Change{
+				Action: ActionCreate, Kind: KindSubscription, Namespace: spec.Namespace, Name: name,
+				apply: func(ctx context.Context) error {
+					req, err := r.subscriptionRequest(ctx, spec)
+					if err != nil {
+						return err
+					}
+					_, err = r.controller.Subscription().Create(ctx, req)
+					return err
+				},
+			})
+			continue
+		}
+
+		var diff []string
+		if eb := ebByID[existing.EventbusId]; eb == nil || eb.Name != spec.Eventbus {
+			diff = append(diff, "eventbus")
+		}
+		diff = append(diff, diffFields(spec.Request.ProtoReflect(), subscriptionRequestOf(existing).ProtoReflect(), "")...)
+		if len(diff) == 0 {
+			continue
+		}
+		plan.Changes = append(plan.Changes, Change{
+			Action: ActionUpdate, Kind: KindSubscription, Namespace: spec.Namespace, Name: name, Diff: diff,
+			apply: func(ctx context.Context) error {
+				req, err := r.subscriptionRequest(ctx, spec)
+				if err != nil {
+					return err
+				}
+				// Unset fields of the desired state keep their current values,
+				// and set ones replace them as a whole, e.g. filters.
+				merged := proto.Clone(subscriptionRequestOf(existing)).(*ctrlpb.SubscriptionRequest)
+				dst := merged.ProtoReflect()
+				req.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+					dst.Set(fd, v)
+					return true
+				})
+				merged.EventbusId, merged.NamespaceId = req.EventbusId, req.NamespaceId
+				return updateSubscription(ctx, r.controller.Subscription(), existing, merged)
+			},
+		})
+	}
+
+	// Deletions, in the reverse order of creations.
+	for _, sub := range subs {
+		sub := sub
+		ns, ok := nsByID[sub.NamespaceId]
+		if !ok || !managed[ns.Name] || wantSub[subKey{ns.Name, sub.Name}] {
+			continue
+		}
+		deletes = append(deletes, Change{
+			Action: ActionDelete, Kind: KindSubscription, Namespace: ns.Name, Name: sub.Name,
+			apply: func(ctx context.Context) error {
+				return r.controller.Subscription().Delete(ctx, WithSubscriptionID(NewID(sub.Id)))
+			},
+		})
+	}
+	for _, eb := range ebs {
+		eb := eb
+		ns, ok := nsByID[eb.NamespaceId]
+		if !ok || !managed[ns.Name] || wantEB[ebKey{ns.Name, eb.Name}] {
+			continue
+		}
+		deletes = append(deletes, Change{
+			Action: ActionDelete, Kind: KindEventbus, Namespace: ns.Name, Name: eb.Name,
+			apply: func(ctx context.Context) error {
+				return r.controller.Eventbus().Delete(ctx, WithEventbusID(NewID(eb.Id)))
+			},
+		})
+	}
+	// Namespaces are never deleted, there is no way to tell a namespace absent
+	// from the document from one which isn't managed.
+	sort.Slice(deletes, func(i, j int) bool {
+		return kindOrder(deletes[i].Kind) < kindOrder(deletes[j].Kind)
+	})
+
+	for _, c := range deletes {
+		if r.opts.prune && !r.protected(c) {
+			plan.Changes = append(plan.Changes, c)
+		} else {
+			plan.Retained = append(plan.Retained, c)
+		}
+	}
+	return plan, nil
+}
+
+func kindOrder(kind string) int {
+	switch kind {
+	case KindSubscription:
+		return 0
+	case KindEventbus:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func (r *Reconciler) protected(c Change) bool {
+	if strings.HasPrefix(c.Name, systemResourcePrefix) || c.Namespace == systemNamespace {
+		return true
+	}
+	return r.opts.protected[c.Kind+"/"+c.Namespace+"/"+c.Name]
+}
+
+// subscriptionRequest resolves the eventbus of spec, which may be created by
+// the plan.
+func (r *Reconciler) subscriptionRequest(ctx context.Context, spec SubscriptionSpec) (*ctrlpb.SubscriptionRequest, error) {
+	eb, err := r.controller.Eventbus().Get(ctx, WithEventbus(spec.Namespace, spec.Eventbus))
+	if err != nil {
+		return nil, err
+	}
+	req := proto.Clone(spec.Request).(*ctrlpb.SubscriptionRequest)
+	req.EventbusId = eb.Id
+	req.NamespaceId = eb.NamespaceId
+	return req, nil
+}
+
+func subscriptionRequestOf(s *metapb.Subscription) *ctrlpb.SubscriptionRequest {
+	return &ctrlpb.SubscriptionRequest{
+		Source:           s.Source,
+		Types:            s.Types,
+		Config:           s.Config,
+		Filters:          s.Filters,
+		Sink:             s.Sink,
+		SinkCredential:   s.SinkCredential,
+		Protocol:         s.Protocol,
+		ProtocolSettings: s.ProtocolSettings,
+		Transformer:      s.Transformer,
+		Name:             s.Name,
+		Description:      s.Description,
+		Disable:          s.Disable,
+		EventbusId:       s.EventbusId,
+		NamespaceId:      s.NamespaceId,
+	}
+}
+
+// diffFields returns the fields which are set in desired but differ in actual,
+// the fields which are unset in desired are left to the server defaults.
+func diffFields(desired, actual protoreflect.Message, prefix string) []string {
+	var diff []string
+	desired.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		name := prefix + string(fd.Name())
+		switch {
+		case fd.Kind() == protoreflect.MessageKind && !fd.IsList() && !fd.IsMap():
+			if !actual.Has(fd) {
+				diff = append(diff, name)
+			} else {
+				diff = append(diff, diffFields(v.Message(), actual.Get(fd).Message(), name+".")...)
+			}
+		case !actual.Has(fd) || !v.Equal(actual.Get(fd)):
+			diff = append(diff, name)
+		}
+		return true
+	})
+	return diff
+}
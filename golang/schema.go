@@ -0,0 +1,310 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vanus
+
+import (
+	// standard libraries.
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	// third-party libraries.
+	v2 "github.com/cloudevents/sdk-go/v2"
+	"github.com/hamba/avro"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+type SchemaType string
+
+const (
+	SchemaTypeJSON     SchemaType = "JSON"
+	SchemaTypeProtobuf SchemaType = "PROTOBUF"
+	SchemaTypeAvro     SchemaType = "AVRO"
+
+	// extSchemaError is set on quarantined events with the reason of the
+	// validation failure.
+	extSchemaError = "schemaerror"
+
+	schemaRegistryPath     = "/schemas"
+	fileSchemaRegistryName = "registry.json"
+)
+
+// Schema describes the data of events whose dataschema is URI.
+type Schema struct {
+	URI  string     `json:"uri"`
+	Type SchemaType `json:"type"`
+	// Definition is the JSON Schema or Avro schema document, or the serialized
+	// FileDescriptorSet of protobuf schemas.
+	Definition []byte `json:"definition"`
+	// MessageName is the full name of the message of protobuf schemas, the last
+	// path segment of URI is used if it's empty.
+	MessageName string `json:"message_name,omitempty"`
+
+	once     sync.Once
+	validate func(data []byte) error
+	err      error
+}
+
+// Validate checks that data conforms to the schema.
+func (s *Schema) Validate(data []byte) error {
+	s.once.Do(func() {
+		s.validate, s.err = s.compile()
+	})
+	if s.err != nil {
+		return s.err
+	}
+	if err := s.validate(data); err != nil {
+		return fmt.Errorf("%w: %s: %s", ErrSchemaValidation, s.URI, err)
+	}
+	return nil
+}
+
+func (s *Schema) compile() (func(data []byte) error, error) {
+	switch s.Type {
+	case SchemaTypeJSON:
+		c := jsonschema.NewCompiler()
+		if err := c.AddResource(s.URI, bytes.NewReader(s.Definition)); err != nil {
+			return nil, err
+		}
+		sch, err := c.Compile(s.URI)
+		if err != nil {
+			return nil, err
+		}
+		return func(data []byte) error {
+			dec := json.NewDecoder(bytes.NewReader(data))
+			dec.UseNumber()
+			var v interface{}
+			if err := dec.Decode(&v); err != nil {
+				return err
+			}
+			return sch.Validate(v)
+		}, nil
+	case SchemaTypeAvro:
+		sch, err := avro.Parse(string(s.Definition))
+		if err != nil {
+			return nil, err
+		}
+		return func(data []byte) error {
+			var v interface{}
+			return avro.Unmarshal(sch, data, &v)
+		}, nil
+	case SchemaTypeProtobuf:
+		fds := &descriptorpb.FileDescriptorSet{}
+		if err := proto.Unmarshal(s.Definition, fds); err != nil {
+			return nil, err
+		}
+		files, err := protodesc.NewFiles(fds)
+		if err != nil {
+			return nil, err
+		}
+		name := s.MessageName
+		if name == "" {
+			name = s.URI[strings.LastIndex(s.URI, "/")+1:]
+		}
+		d, err := files.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			return nil, err
+		}
+		md, ok := d.(protoreflect.MessageDescriptor)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a message", name)
+		}
+		return func(data []byte) error {
+			m := dynamicpb.NewMessage(md)
+			if err := proto.Unmarshal(data, m); err != nil {
+				return err
+			}
+			if len(m.GetUnknown()) != 0 {
+				return fmt.Errorf("unknown fields in %s", name)
+			}
+			return nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported schema type: %s", s.Type)
+	}
+}
+
+// SchemaRegistry resolves the dataschema of events to their schemas.
+type SchemaRegistry interface {
+	Resolve(ctx context.Context, uri string) (*Schema, error)
+}
+
+type fileSchemaRegistry struct {
+	schemas map[string]*Schema
+}
+
+type fileSchemaEntry struct {
+	URI         string     `json:"uri"`
+	Type        SchemaType `json:"type"`
+	File        string     `json:"file"`
+	MessageName string     `json:"message_name,omitempty"`
+}
+
+// NewFileSchemaRegistry loads schemas from dir, which contains a registry.json
+// listing the schemas, e.g.
+//
+//	[{"uri": "https://example.com/order.json", "type": "JSON", "file": "order.json"}]
+//
+// The file of protobuf schemas is a serialized FileDescriptorSet, as generated
+// by `protoc --descriptor_set_out --include_imports`.
+func NewFileSchemaRegistry(dir string) (SchemaRegistry, error) {
+	data, err := os.ReadFile(filepath.Join(dir, fileSchemaRegistryName))
+	if err != nil {
+		return nil, err
+	}
+	var entries []fileSchemaEntry
+	if err = json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", fileSchemaRegistryName, err)
+	}
+	r := &fileSchemaRegistry{schemas: make(map[string]*Schema, len(entries))}
+	for _, entry := range entries {
+		def, err := os.ReadFile(filepath.Join(dir, entry.File))
+		if err != nil {
+			return nil, err
+		}
+		r.schemas[entry.URI] = &Schema{
+			URI:         entry.URI,
+			Type:        entry.Type,
+			Definition:  def,
+			MessageName: entry.MessageName,
+		}
+	}
+	return r, nil
+}
+
+func (r *fileSchemaRegistry) Resolve(_ context.Context, uri string) (*Schema, error) {
+	s, ok := r.schemas[uri]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSchemaNotFound, uri)
+	}
+	return s, nil
+}
+
+type httpSchemaRegistry struct {
+	endpoint string
+	client   *http.Client
+	cache    sync.Map
+}
+
+// NewHTTPSchemaRegistry returns a registry which resolves schemas by
+// `GET {endpoint}/schemas?uri={uri}`, the response is the JSON encoded Schema.
+// The protocol is served by NewSchemaRegistryHandler.
+func NewHTTPSchemaRegistry(endpoint string, client *http.Client) SchemaRegistry {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &httpSchemaRegistry{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		client:   client,
+	}
+}
+
+func (r *httpSchemaRegistry) Resolve(ctx context.Context, uri string) (*Schema, error) {
+	if v, ok := r.cache.Load(uri); ok {
+		return v.(*Schema), nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		r.endpoint+schemaRegistryPath+"?uri="+url.QueryEscape(uri), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrSchemaNotFound, uri)
+	default:
+		return nil, fmt.Errorf("schema registry responded %s", resp.Status)
+	}
+	s := &Schema{}
+	if err = json.NewDecoder(resp.Body).Decode(s); err != nil {
+		return nil, err
+	}
+	v, _ := r.cache.LoadOrStore(uri, s)
+	return v.(*Schema), nil
+}
+
+// NewSchemaRegistryHandler serves the protocol of NewHTTPSchemaRegistry by reg,
+// e.g. to share a file based registry with other processes.
+func NewSchemaRegistryHandler(reg SchemaRegistry) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(schemaRegistryPath, func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		s, err := reg.Resolve(req.Context(), req.URL.Query().Get("uri"))
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrSchemaNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		_ = json.NewEncoder(w).Encode(s)
+	})
+	return mux
+}
+
+// validateEvent validates the data of e by the schema of its dataschema, events
+// without dataschema are always valid.
+func validateEvent(ctx context.Context, reg SchemaRegistry, e *v2.Event) error {
+	if e.DataSchema() == "" {
+		return nil
+	}
+	s, err := reg.Resolve(ctx, e.DataSchema())
+	if err != nil {
+		return err
+	}
+	return s.Validate(e.Data())
+}
+
+// SchemaMessage is a Message with the schema resolved from the dataschema of
+// its event, which the messages of subscribers implement.
+type SchemaMessage interface {
+	Message
+	Schema() *Schema
+}
+
+// SchemaOf returns the schema of the message, it's nil if the client has no
+// SchemaRegistry, the event has no dataschema or msg isn't a SchemaMessage.
+func SchemaOf(msg Message) *Schema {
+	if sm, ok := msg.(SchemaMessage); ok {
+		return sm.Schema()
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vanus
+
+import (
+	// standard libraries.
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	// third-party libraries.
+	"google.golang.org/protobuf/proto"
+
+	// first-party libraries.
+	ctrlpb "github.com/vanus-labs/vanus/api/controller"
+	metapb "github.com/vanus-labs/vanus/api/meta"
+)
+
+// deregisterTimeout bounds pausing or deleting a self-registered subscription
+// on Close.
+const deregisterTimeout = 10 * time.Second
+
+// CloseAction is what a self-registered subscriber does to its subscription
+// on Close.
+type CloseAction int
+
+const (
+	// CloseKeep leaves the subscription pushing to the advertised address.
+	CloseKeep CloseAction = iota
+	// ClosePause pauses the subscription, it's resumed by the Listen of the
+	// next subscriber, e.g. the one Client.Subscriber returns after Close.
+	ClosePause
+	// CloseDelete deletes the subscription, e.g. of an ephemeral consumer.
+	CloseDelete
+)
+
+type selfRegistration struct {
+	spec      SubscriptionSpec
+	advertise string
+	onClose   CloseAction
+}
+
+// WithSelfRegistration provisions the subscription of spec in passive mode:
+// Listen creates it, or updates it to push to advertise, and resumes it, so
+// the subscriber is ready in one call:
+//
+//	sub := c.Subscriber(vanus.WithSelfRegistration(vanus.SubscriptionSpec{
+//		Namespace: "default",
+//		Eventbus:  "orders",
+//		Request:   &ctrlpb.SubscriptionRequest{Name: "preview-42", Filters: filters},
+//	}, "http://10.0.0.5:8080", vanus.CloseDelete))
+//
+// The sink of the subscription is advertise, or http://advertise if it has no
+// scheme, and the protocol is the one of WithProtocol. The subscription is
+// addressed by the name of spec in its namespace afterwards.
+func WithSelfRegistration(spec SubscriptionSpec, advertise string, onClose CloseAction) SubscriptionOption {
+	return func(opt *subscriptionOptions) {
+		opt.selfRegistration = &selfRegistration{spec: spec, advertise: advertise, onClose: onClose}
+		opt.namespace = spec.Namespace
+		opt.subscriptionName = spec.Request.GetName()
+	}
+}
+
+// register creates or updates the subscription to push to the subscriber,
+// and resumes it.
+func (s *subscribe) register(ctx context.Context) error {
+	r := s.options.selfRegistration
+	if s.options.activeMode {
+		return fmt.Errorf("%w: self-registration is of passive mode", ErrInvalidArguments)
+	}
+	if r.spec.Request.GetName() == "" || r.advertise == "" {
+		return fmt.Errorf("%w: self-registration needs a subscription name and an advertised address",
+			ErrInvalidArguments)
+	}
+	eb, err := s.eventbus.Get(ctx, WithEventbus(r.spec.Namespace, r.spec.Eventbus))
+	if err != nil {
+		return err
+	}
+	req := proto.Clone(r.spec.Request).(*ctrlpb.SubscriptionRequest)
+	req.EventbusId = eb.Id
+	req.NamespaceId = eb.NamespaceId
+	req.Sink = r.advertise
+	if !strings.Contains(req.Sink, "://") {
+		req.Sink = "http://" + req.Sink
+	}
+	req.Protocol = metapb.Protocol_HTTP
+	if s.options.protocol == ProtocolGRPC {
+		req.Protocol = metapb.Protocol_GRPC
+	}
+	// A paused subscription is resumed below, instead of updated to run.
+	req.Disable = false
+
+	existing, err := s.controller.Get(ctx, WithSubscriptionName(r.spec.Namespace, req.Name))
+	switch {
+	case err == ErrSubscriptionNotFound:
+		existing, err = s.controller.Ensure(ctx, req)
+		if err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	case existing.EventbusId != req.EventbusId:
+		return fmt.Errorf("%w: %s subscribes to another eventbus", ErrSubscriptionConflict, req.Name)
+	default:
+		if err = s.update(ctx, existing, req); err != nil {
+			return err
+		}
+	}
+	s.setSubscriptionID(NewID(existing.Id))
+	if existing.Disable {
+		return s.controller.Resume(ctx, WithSubscriptionID(NewID(existing.Id)))
+	}
+	return nil
+}
+
+// update updates the subscription if it differs from req, see
+// updateSubscription.
+func (s *subscribe) update(ctx context.Context, existing *metapb.Subscription, req *ctrlpb.SubscriptionRequest) error {
+	if len(diffFields(req.ProtoReflect(), subscriptionRequestOf(existing).ProtoReflect(), "")) == 0 {
+		return nil
+	}
+	return updateSubscription(ctx, s.controller, existing, req)
+}
+
+// deregister pauses or deletes the self-registered subscription on Close.
+func (s *subscribe) deregister() error {
+	r := s.options.selfRegistration
+	id := s.SubscriptionID()
+	if r == nil || id == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), deregisterTimeout)
+	defer cancel()
+	switch r.onClose {
+	case ClosePause:
+		return s.controller.Pause(ctx, WithSubscriptionID(id))
+	case CloseDelete:
+		return s.controller.Delete(ctx, WithSubscriptionID(id))
+	default:
+		return nil
+	}
+}
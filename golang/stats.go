@@ -0,0 +1,155 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vanus
+
+import (
+	// standard libraries.
+	"context"
+	"math"
+	"time"
+
+	// first-party libraries.
+	ctrlpb "github.com/vanus-labs/vanus/api/controller"
+	metapb "github.com/vanus-labs/vanus/api/meta"
+)
+
+// segmentPageSize is the number of segments listed per request.
+const segmentPageSize = 64
+
+// EventbusStats is the statistics of an eventbus, which is the sum of its
+// eventlogs.
+type EventbusStats struct {
+	ID        ID              `json:"id"`
+	Name      string          `json:"name"`
+	Events    int64           `json:"events"`
+	Bytes     int64           `json:"bytes"`
+	Capacity  int64           `json:"capacity"`
+	Segments  int             `json:"segments"`
+	FirstAt   time.Time       `json:"first_event_at"`
+	LastAt    time.Time       `json:"last_event_at"`
+	Eventlogs []EventlogStats `json:"eventlogs"`
+}
+
+// EventlogStats is the statistics of an eventlog.
+type EventlogStats struct {
+	ID             ID    `json:"id"`
+	EarliestOffset int64 `json:"earliest_offset"`
+	// LatestOffset is the offset of the next event.
+	LatestOffset int64 `json:"latest_offset"`
+	Events       int64 `json:"events"`
+	// Bytes is approximate, it includes the overhead of the storage.
+	Bytes    int64 `json:"bytes"`
+	Capacity int64 `json:"capacity"`
+	Segments int   `json:"segments"`
+	// SegmentStates is the number of segments by their states, e.g. working,
+	// frozen and archived.
+	SegmentStates map[string]int `json:"segment_states"`
+	FirstAt       time.Time      `json:"first_event_at"`
+	LastAt        time.Time      `json:"last_event_at"`
+}
+
+func (eb *eventbus) Stats(ctx context.Context, opts ...EventbusOption) (*EventbusStats, error) {
+	pb, err := eb.get(ctx, newEventbusOptions(opts...))
+	if err != nil {
+		return nil, err
+	}
+	stats := &EventbusStats{ID: NewID(pb.Id), Name: pb.Name, Eventlogs: make([]EventlogStats, 0, len(pb.Logs))}
+	for _, l := range pb.Logs {
+		segments, err := eb.listSegments(ctx, pb.Id, l.EventlogId)
+		if err != nil {
+			return nil, err
+		}
+		ls := newEventlogStats(l.EventlogId, segments)
+		stats.Events += ls.Events
+		stats.Bytes += ls.Bytes
+		stats.Capacity += ls.Capacity
+		stats.Segments += ls.Segments
+		stats.FirstAt = earlier(stats.FirstAt, ls.FirstAt)
+		if ls.LastAt.After(stats.LastAt) {
+			stats.LastAt = ls.LastAt
+		}
+		stats.Eventlogs = append(stats.Eventlogs, ls)
+	}
+	return stats, nil
+}
+
+// listSegments lists all segments of the eventlog page by page.
+func (eb *eventbus) listSegments(ctx context.Context, eventbusID, eventlogID uint64) ([]*metapb.Segment, error) {
+	var segments []*metapb.Segment
+	var start int64
+	for {
+		res, err := eb.controller.ListSegment(ctx, &ctrlpb.ListSegmentRequest{
+			EventbusId:  eventbusID,
+			EventlogId:  eventlogID,
+			StartOffset: start,
+			EndOffset:   math.MaxInt64,
+			Limited:     segmentPageSize,
+		})
+		if err != nil {
+			return nil, err
+		}
+		page := res.GetSegments()
+		for _, seg := range page {
+			// The segment containing start is returned again.
+			if len(segments) == 0 || seg.Id != segments[len(segments)-1].Id {
+				segments = append(segments, seg)
+			}
+		}
+		if len(page) < segmentPageSize {
+			return segments, nil
+		}
+		next := page[len(page)-1].EndOffsetInLog
+		if next <= start {
+			return segments, nil
+		}
+		start = next
+	}
+}
+
+func newEventlogStats(id uint64, segments []*metapb.Segment) EventlogStats {
+	ls := EventlogStats{
+		ID:            NewID(id),
+		Segments:      len(segments),
+		SegmentStates: map[string]int{},
+	}
+	for i, seg := range segments {
+		if i == 0 || seg.StartOffsetInLog < ls.EarliestOffset {
+			ls.EarliestOffset = seg.StartOffsetInLog
+		}
+		// The end offset of the working segment isn't settled.
+		end := seg.StartOffsetInLog + int64(seg.NumberEventStored)
+		if end > ls.LatestOffset {
+			ls.LatestOffset = end
+		}
+		ls.Events += int64(seg.NumberEventStored)
+		ls.Bytes += seg.Size
+		ls.Capacity += seg.Capacity
+		ls.SegmentStates[seg.State]++
+		if seg.FirstEventBornAtByUnixMs > 0 {
+			ls.FirstAt = earlier(ls.FirstAt, time.UnixMilli(seg.FirstEventBornAtByUnixMs))
+		}
+		if t := time.UnixMilli(seg.LastEventBornAtByUnixMs); seg.LastEventBornAtByUnixMs > 0 && t.After(ls.LastAt) {
+			ls.LastAt = t
+		}
+	}
+	return ls
+}
+
+func earlier(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
@@ -32,15 +32,19 @@ import (
 type ackCallback func(err error)
 
 type message struct {
-	event   *v2.Event
-	ack     ackCallback
-	ackFlag atomic.Bool
+	event     *v2.Event
+	schema    *Schema
+	ack       ackCallback
+	ackFlag   atomic.Bool
+	onSuccess func()
 }
 
-func newMessage(cb ackCallback, e *v2.Event) Message {
+func newMessage(cb ackCallback, e *v2.Event, schema *Schema, onSuccess func()) Message {
 	return &message{
-		event: e,
-		ack:   cb,
+		event:     e,
+		schema:    schema,
+		ack:       cb,
+		onSuccess: onSuccess,
 	}
 }
 
@@ -48,9 +52,16 @@ func (m *message) GetEvent() *v2.Event {
 	return m.event
 }
 
+func (m *message) Schema() *Schema {
+	return m.schema
+}
+
 func (m *message) Success() {
 	if m.ackFlag.CAS(false, true) {
 		m.ack(nil)
+		if m.onSuccess != nil {
+			m.onSuccess()
+		}
 	}
 }
 
@@ -61,21 +72,51 @@ func (m *message) Failed(err error) {
 }
 
 type subscribe struct {
-	store           proxypb.StoreProxyClient
-	options         subscriptionOptions
-	subscribeStream proxypb.StoreProxy_SubscribeClient
-	ackStream       proxypb.StoreProxy_AckClient
-	messageC        chan Message
-	state           streamState
-	mu              sync.Mutex
-	closeOnce       sync.Once
-	handler         func(ctx context.Context, msgs ...Message) error
-	closeC          chan struct{}
+	store      proxypb.StoreProxyClient
+	options    subscriptionOptions
+	messageC   chan Message
+	state      streamState
+	mu         sync.Mutex
+	closeOnce  sync.Once
+	handler    func(ctx context.Context, msgs ...Message) error
+	closeC     chan struct{}
+	registry   SchemaRegistry
+	controller Subscription
+	eventbus   Eventbus
+	cache      *metadataCache
+	// cancel stops receiving in active mode.
+	cancel context.CancelFunc
 }
 
 func (s *subscribe) Listen(handler func(ctx context.Context, msgs ...Message) error) error {
 	s.handler = handler
+	if s.options.selfRegistration != nil {
+		if err := s.register(context.Background()); err != nil {
+			return err
+		}
+	}
+	if s.SubscriptionID() == 0 && s.options.subscriptionName != "" {
+		sub, err := s.controller.Get(context.Background(),
+			WithSubscriptionName(s.options.namespace, s.options.subscriptionName))
+		if err != nil {
+			return err
+		}
+		s.setSubscriptionID(NewID(sub.Id))
+	}
+	if g := s.options.lagGauge; g != nil && s.options.lagInterval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			<-s.closeC
+			cancel()
+		}()
+		go g.poll(ctx, s.controller, s.options.lagInterval, WithSubscriptionID(s.SubscriptionID()))
+	}
+	if s.options.order {
+		go s.dispatchByKey()
+		return s.startReceive()
+	}
 	go func() {
+		// barrier isn't closed on close, as handlers in flight still release it.
 		barrier := make(chan struct{}, s.options.parallelism)
 		for idx := 0; idx < s.options.parallelism; idx++ {
 			barrier <- struct{}{}
@@ -84,10 +125,10 @@ func (s *subscribe) Listen(handler func(ctx context.Context, msgs ...Message) er
 			select {
 			case <-s.closeC:
 				// TODO log
-				goto CLOSE
+				return
 			case msg := <-s.messageC:
 				if msg == nil {
-					goto CLOSE // TODO(wenfeng) how to process if msg is nil?
+					return // TODO(wenfeng) how to process if msg is nil?
 				}
 				<-barrier
 				l := len(s.messageC)
@@ -103,53 +144,160 @@ func (s *subscribe) Listen(handler func(ctx context.Context, msgs ...Message) er
 				}()
 			}
 		}
-	CLOSE:
-		close(barrier)
 	}()
 	return s.startReceive()
 }
 
+// dispatchByKey dispatches messages to lanes by their partition keys, so the
+// messages of a key are handled in order by the same lane.
+func (s *subscribe) dispatchByKey() {
+	n := s.options.parallelism
+	if n < 1 {
+		n = 1
+	}
+	lanes := make([]chan Message, n)
+	for idx := range lanes {
+		lanes[idx] = make(chan Message, s.options.batchSize)
+		go s.handleLane(lanes[idx])
+	}
+	defer func() {
+		for _, lane := range lanes {
+			close(lane)
+		}
+	}()
+	next := 0
+	for {
+		select {
+		case <-s.closeC:
+			return
+		case msg := <-s.messageC:
+			if msg == nil {
+				return
+			}
+			var idx int
+			if key := PartitionKey(msg.GetEvent()); key != "" {
+				idx = jumpHash(hashKey(key), len(lanes))
+			} else {
+				// Messages without key are unordered.
+				idx, next = next, (next+1)%len(lanes)
+			}
+			lanes[idx] <- msg
+		}
+	}
+}
+
+func (s *subscribe) handleLane(lane chan Message) {
+	for msg := range lane {
+		msgs := []Message{msg}
+	BATCH:
+		for len(msgs) < s.options.batchSize {
+			select {
+			case m, ok := <-lane:
+				if !ok {
+					break BATCH
+				}
+				msgs = append(msgs, m)
+			default:
+				break BATCH
+			}
+		}
+		// TODO(wenfeng) How to process error?
+		_ = s.handler(context.Background(), msgs...)
+	}
+}
+
 func (s *subscribe) Close() error {
+	var err error
 	s.closeOnce.Do(func() {
-		_ = s.subscribeStream.CloseSend()
-		s.subscribeStream = nil
-		_ = s.ackStream.CloseSend()
-		s.ackStream = nil
-		close(s.messageC)
-		close(s.closeC)
+		s.mu.Lock()
+		if s.cancel != nil {
+			s.cancel()
+		}
 		s.state = stateClosed
+		s.mu.Unlock()
+		// The receiving loop closes its streams. messageC is left open, as
+		// pushed events may still be sent to it, its consumers stop by closeC.
+		close(s.closeC)
+		err = s.deregister()
 	})
-	return nil
+	return err
+}
+
+// closed reports whether the subscriber is closed.
+func (s *subscribe) closed() bool {
+	select {
+	case <-s.closeC:
+		return true
+	default:
+		return false
+	}
 }
 
-func newSubscriber(cc *grpc.ClientConn, opts subscriptionOptions) Subscriber {
+func newSubscriber(cc *grpc.ClientConn, opts subscriptionOptions, registry SchemaRegistry,
+	cache *metadataCache) Subscriber {
 	return &subscribe{
 		store:    proxypb.NewStoreProxyClient(cc),
 		options:  opts,
 		messageC: make(chan Message, 32),
 		closeC:   make(chan struct{}),
 		state:    stateInitialized,
+		registry: registry,
+		cache:    cache,
+		controller: &subscription{
+			controller: proxypb.NewControllerProxyClient(cc),
+			cache:      cache,
+		},
+		eventbus: &eventbus{
+			controller: proxypb.NewControllerProxyClient(cc),
+			cache:      cache,
+		},
 	}
 }
 
+// SubscriptionID returns the ID of the subscription, which is changed when
+// it's resolved again by name while receiving.
 func (s *subscribe) SubscriptionID() ID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.options.subscriptionID
 }
 
+func (s *subscribe) setSubscriptionID(id ID) {
+	s.mu.Lock()
+	s.options.subscriptionID = id
+	s.mu.Unlock()
+}
+
 func (s *subscribe) Send(ctx context.Context, event *cloudevents.BatchEvent) (*emptypb.Empty, error) {
-	ch := make(chan error, 1)
-	s.processCloudEvents(event.Events, func(err error) {
-		ch <- err
+	if err := s.deliver(ctx, event.Events); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *subscribe) subscribe(ctx context.Context) (proxypb.StoreProxy_SubscribeClient, error) {
+	return s.store.Subscribe(ctx, &proxypb.SubscribeRequest{
+		SubscriptionId: s.SubscriptionID().Hex(), // TODO(wenfeng) change to id in next release
 	})
-	err := <-ch
+}
+
+// reresolve drops the cached subscription, resolves its name again and
+// subscribes to it, e.g. after it's deleted and recreated with the same name.
+func (s *subscribe) reresolve(ctx context.Context) (proxypb.StoreProxy_SubscribeClient, error) {
+	s.cache.invalidate(subscriptionCacheKey(s.options.namespace, s.options.subscriptionName))
+	sub, err := s.controller.Get(ctx, WithSubscriptionName(s.options.namespace, s.options.subscriptionName))
 	if err != nil {
 		return nil, err
 	}
-	return &emptypb.Empty{}, nil
+	s.setSubscriptionID(NewID(sub.Id))
+	return s.subscribe(ctx)
 }
 
 func (s *subscribe) startReceive() error {
 	if !s.options.activeMode {
+		if ps := s.options.pushServer; ps != nil {
+			return ps.serve(s)
+		}
 		srv := grpc.NewServer()
 		cloudevents.RegisterCloudEventsServer(srv, s)
 		listen, err := net.Listen("tcp", fmt.Sprintf(":%d", s.options.port))
@@ -158,37 +306,59 @@ func (s *subscribe) startReceive() error {
 		}
 		return srv.Serve(listen)
 	} else {
-		ctx := context.Background()
-		var err error
-		in := &proxypb.SubscribeRequest{
-			SubscriptionId: s.SubscriptionID().Hex(), // TODO(wenfeng) change to id in next release
+		ctx, cancel := context.WithCancel(context.Background())
+		s.mu.Lock()
+		s.cancel = cancel
+		s.mu.Unlock()
+		select {
+		case <-s.closeC:
+			// Closed before receiving.
+			cancel()
+			return nil
+		default:
 		}
-		s.subscribeStream, err = s.store.Subscribe(ctx, in)
+		stream, err := s.subscribe(ctx)
 		if err != nil {
 			_ = s.Close()
 			return err
 		}
-		s.ackStream, err = s.store.Ack(ctx)
+		// The subscription is resolved again at most once, if it's addressed
+		// by name and not found before receiving any events.
+		reresolvable := s.options.subscriptionName != ""
+		ackStream, err := s.store.Ack(ctx)
 		if err != nil {
 			_ = s.Close()
 			return err
 		}
+		defer func() {
+			_ = ackStream.CloseSend()
+		}()
 		for {
 			select {
 			case <-s.closeC:
+				return nil
 			default:
-				resp, err := s.subscribeStream.Recv()
+				resp, err := stream.Recv()
+				if err != nil && reresolvable && isNotFound(err) {
+					reresolvable = false
+					if stream, err = s.reresolve(ctx); err == nil {
+						continue
+					}
+				}
 				if err != nil {
 					return s.Close()
 				}
+				reresolvable = false
 
+				// Acknowledge to the subscription which the events are received from.
+				subscriptionID := s.SubscriptionID()
 				ackFunc := func(err error) {
 					req := &proxypb.AckRequest{
 						SequenceId:     resp.SequenceId,
-						SubscriptionId: s.options.subscriptionID.Hex(), // TODO(wenfeng) change to id in next release
+						SubscriptionId: subscriptionID.Hex(), // TODO(wenfeng) change to id in next release
 						Success:        err == nil,
 					}
-					_err := s.ackStream.Send(req)
+					_err := ackStream.Send(req)
 					if _err != nil {
 						_ = s.Close()
 					}
@@ -205,23 +375,75 @@ func (s *subscribe) startReceive() error {
 
 func (s *subscribe) processCloudEvents(batch *cloudevents.CloudEventBatch, cb ackCallback) {
 	size := atomic.NewInt32(int32(len(batch.GetEvents())))
+	// The batch is acknowledged once, by the first failure or the last success,
+	// so callers waiting for one result don't block on a second.
+	acked := atomic.NewBool(false)
 	_ackFunc := func(err error) {
-		if err == nil {
-			if size.Dec() == 0 {
-				cb(nil)
-			}
-		} else {
+		if err == nil && size.Dec() != 0 {
+			return
+		}
+		if acked.CAS(false, true) {
 			cb(err)
 		}
 	}
 	if events := batch.GetEvents(); len(events) > 0 {
 		for _, e := range events {
 			event, err2 := FromProto(e)
+			var onSuccess func()
+			if err2 == nil {
+				onSuccess, err2 = s.decode(event)
+			}
 			if err2 != nil {
 				// TODO(JiangKai): check err
+				_ackFunc(err2)
 				continue
 			}
-			s.messageC <- newMessage(_ackFunc, event)
+			if s.options.lagGauge != nil {
+				s.options.lagGauge.observe(event)
+			}
+			select {
+			case s.messageC <- newMessage(_ackFunc, event, s.resolveSchema(event), onSuccess):
+			case <-s.closeC:
+				_ackFunc(ErrSubscriberClosed)
+				return
+			}
+		}
+	}
+}
+
+func (s *subscribe) resolveSchema(e *v2.Event) *Schema {
+	if s.registry == nil || e.DataSchema() == "" {
+		return nil
+	}
+	schema, err := s.registry.Resolve(context.Background(), e.DataSchema())
+	if err != nil {
+		// TODO(wenfeng) log
+		return nil
+	}
+	return schema
+}
+
+// decode restores the data of the event which is transformed by the publisher,
+// and returns the callback after the message succeeds.
+func (s *subscribe) decode(e *v2.Event) (func(), error) {
+	ctx := context.Background()
+	var onSuccess func()
+	if s.options.blobStore != nil {
+		ref, err := checkOutEvent(ctx, e, s.options.blobStore)
+		if err != nil {
+			return nil, err
+		}
+		if ref != "" && s.options.blobCleanup {
+			onSuccess = func() {
+				// TODO(wenfeng) log
+				_ = s.options.blobStore.Delete(context.Background(), ref)
+			}
+		}
+	}
+	if s.options.keyProvider != nil {
+		if err := decryptEvent(ctx, e, s.options.keyProvider); err != nil {
+			return nil, err
 		}
 	}
+	return onSuccess, decompressEvent(e)
 }
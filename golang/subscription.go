@@ -16,6 +16,9 @@ package vanus
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 
 	ctrlpb "github.com/vanus-labs/vanus/api/controller"
 	"github.com/vanus-labs/vanus/api/errors"
@@ -29,29 +32,11 @@ var (
 
 type subscription struct {
 	controller proxypb.ControllerProxyClient
-}
-
-func (s *subscription) List(ctx context.Context) ([]*metapb.Subscription, error) {
-	res, err := s.controller.ListSubscription(ctx, &ctrlpb.ListSubscriptionRequest{})
-	if err != nil {
-		return nil, err
-	}
-	return res.GetSubscription(), nil
+	cache      *metadataCache
 }
 
 func (s *subscription) Get(ctx context.Context, opts ...SubscriptionOption) (*metapb.Subscription, error) {
-	o := newSubscriptionOptions(opts...)
-	if o.subscriptionID == 0 {
-		return nil, ErrSubscriptionIDIsZero
-	}
-	subscription, err := s.controller.GetSubscription(ctx, &ctrlpb.GetSubscriptionRequest{Id: uint64(o.subscriptionID)})
-	if err != nil {
-		if errors.Is(err, errors.ErrResourceNotFound) {
-			return nil, ErrSubscriptionNotFound
-		}
-		return nil, err
-	}
-	return subscription, nil
+	return s.get(ctx, newSubscriptionOptions(opts...))
 }
 
 func (s *subscription) Create(ctx context.Context, request *ctrlpb.SubscriptionRequest, opts ...SubscriptionOption) (*metapb.Subscription, error) {
@@ -72,16 +57,109 @@ func (s *subscription) Create(ctx context.Context, request *ctrlpb.SubscriptionR
 	return s.controller.CreateSubscription(ctx, req)
 }
 
+func (s *subscription) Ensure(ctx context.Context, request *ctrlpb.SubscriptionRequest, opts ...SubscriptionOption) (*metapb.Subscription, error) {
+	o := newSubscriptionOptions(opts...)
+	byOptions := o.subscriptionID != 0 || o.subscriptionName != ""
+	if !byOptions && (request.GetName() == "" || request.GetEventbusId() == 0) {
+		return nil, ErrInvalidArguments
+	}
+	existing, err := s.find(ctx, o, request)
+	if err == ErrSubscriptionNotFound {
+		existing, err = s.Create(ctx, request, opts...)
+		switch {
+		case err == ErrSubscriptionExist || errors.Is(err, errors.ErrResourceAlreadyExist):
+			// Created by a concurrent caller.
+			existing, err = s.find(ctx, o, request)
+		case err == nil && !byOptions:
+			existing, err = s.dedup(ctx, existing)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if diff := diffFields(request.ProtoReflect(), subscriptionRequestOf(existing).ProtoReflect(), ""); len(diff) != 0 {
+		return nil, fmt.Errorf("%w: %s differs in %s", ErrSubscriptionConflict, existing.Name, strings.Join(diff, ", "))
+	}
+	return existing, nil
+}
+
+// find returns the subscription by the ID or name of opts, or by the name and
+// eventbus of request.
+func (s *subscription) find(ctx context.Context, opts subscriptionOptions,
+	request *ctrlpb.SubscriptionRequest) (*metapb.Subscription, error) {
+	if opts.subscriptionID != 0 || opts.subscriptionName != "" {
+		return s.get(ctx, opts)
+	}
+	subs, err := s.listByName(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if len(subs) == 0 {
+		return nil, ErrSubscriptionNotFound
+	}
+	return subs[0], nil
+}
+
+// dedup resolves the duplicates created by concurrent callers if the server
+// doesn't reject them: the one with the lowest ID wins, and the others are
+// deleted by their creators.
+func (s *subscription) dedup(ctx context.Context, created *metapb.Subscription) (*metapb.Subscription, error) {
+	subs, err := s.listByName(ctx, &ctrlpb.SubscriptionRequest{
+		Name:        created.Name,
+		EventbusId:  created.EventbusId,
+		NamespaceId: created.NamespaceId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(subs) == 0 || subs[0].Id == created.Id {
+		return created, nil
+	}
+	if _, err = s.controller.DeleteSubscription(ctx, &ctrlpb.DeleteSubscriptionRequest{Id: created.Id}); err != nil {
+		return nil, err
+	}
+	s.invalidate(NewID(created.Id))
+	return subs[0], nil
+}
+
+// listByName returns the subscriptions of the name, ordered by ID.
+func (s *subscription) listByName(ctx context.Context, request *ctrlpb.SubscriptionRequest) ([]*metapb.Subscription, error) {
+	res, err := s.controller.ListSubscription(ctx, &ctrlpb.ListSubscriptionRequest{
+		Name:        request.GetName(),
+		EventbusId:  request.GetEventbusId(),
+		NamespaceId: request.GetNamespaceId(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var subs []*metapb.Subscription
+	for _, sub := range res.GetSubscription() {
+		// Filter again in case the server ignores the conditions.
+		if sub.Name == request.GetName() && sub.EventbusId == request.GetEventbusId() {
+			subs = append(subs, sub)
+		}
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].Id < subs[j].Id })
+	return subs, nil
+}
+
 func (s *subscription) Update(ctx context.Context,
-	request *ctrlpb.UpdateSubscriptionRequest) (*metapb.Subscription, error) {
+	request *ctrlpb.UpdateSubscriptionRequest, opts ...SubscriptionOption) (*metapb.Subscription, error) {
+	if request.GetId() == 0 {
+		o := newSubscriptionOptions(opts...)
+		if err := s.resolve(ctx, &o); err != nil {
+			return nil, err
+		}
+		request.Id = uint64(o.subscriptionID)
+	}
 	return s.controller.UpdateSubscription(ctx, request)
 }
 
 func (s *subscription) Delete(ctx context.Context, opts ...SubscriptionOption) error {
 	o := newSubscriptionOptions(opts...)
 
-	if o.subscriptionID == 0 {
-		return ErrSubscriptionIDIsZero
+	if err := s.resolve(ctx, &o); err != nil {
+		return err
 	}
 	_, err := s.controller.DeleteSubscription(ctx, &ctrlpb.DeleteSubscriptionRequest{
 		Id: uint64(o.subscriptionID),
@@ -89,6 +167,7 @@ func (s *subscription) Delete(ctx context.Context, opts ...SubscriptionOption) e
 	if err != nil {
 		return err
 	}
+	s.invalidate(o.subscriptionID)
 	return nil
 }
 
@@ -110,8 +189,8 @@ func (s *subscription) Delete(ctx context.Context, opts ...SubscriptionOption) e
 func (s *subscription) Pause(ctx context.Context, opts ...SubscriptionOption) error {
 	o := newSubscriptionOptions(opts...)
 
-	if o.subscriptionID == 0 {
-		return ErrSubscriptionIDIsZero
+	if err := s.resolve(ctx, &o); err != nil {
+		return err
 	}
 	_, err := s.controller.DisableSubscription(ctx, &ctrlpb.DisableSubscriptionRequest{
 		Id: uint64(o.subscriptionID),
@@ -125,8 +204,8 @@ func (s *subscription) Pause(ctx context.Context, opts ...SubscriptionOption) er
 func (s *subscription) Resume(ctx context.Context, opts ...SubscriptionOption) error {
 	o := newSubscriptionOptions(opts...)
 
-	if o.subscriptionID == 0 {
-		return ErrSubscriptionIDIsZero
+	if err := s.resolve(ctx, &o); err != nil {
+		return err
 	}
 	_, err := s.controller.ResumeSubscription(ctx, &ctrlpb.ResumeSubscriptionRequest{
 		Id: uint64(o.subscriptionID),
@@ -137,9 +216,30 @@ func (s *subscription) Resume(ctx context.Context, opts ...SubscriptionOption) e
 	return nil
 }
 
+// updateSubscription updates existing to req. The server only updates a paused
+// subscription, so a running one is paused first, and resumed afterwards even
+// if the update fails, unless req disables it.
+func updateSubscription(ctx context.Context, c Subscription, existing *metapb.Subscription,
+	req *ctrlpb.SubscriptionRequest) error {
+	id := WithSubscriptionID(NewID(existing.Id))
+	running := !existing.Disable
+	if running {
+		if err := c.Pause(ctx, id); err != nil {
+			return err
+		}
+	}
+	_, err := c.Update(ctx, &ctrlpb.UpdateSubscriptionRequest{Id: existing.Id, Subscription: req})
+	if running && (err != nil || !req.Disable) {
+		if resumeErr := c.Resume(ctx, id); err == nil {
+			err = resumeErr
+		}
+	}
+	return err
+}
+
 func (s *subscription) get(ctx context.Context, opts subscriptionOptions) (*metapb.Subscription, error) {
-	if opts.subscriptionID == 0 {
-		return nil, ErrSubscriptionIDIsZero
+	if err := s.resolve(ctx, &opts); err != nil {
+		return nil, err
 	}
 	subscription, err := s.controller.GetSubscription(ctx, &ctrlpb.GetSubscriptionRequest{Id: uint64(opts.subscriptionID)})
 	if err != nil {
@@ -150,3 +250,53 @@ func (s *subscription) get(ctx context.Context, opts subscriptionOptions) (*meta
 	}
 	return subscription, nil
 }
+
+// resolve sets the subscription id of opts by WithSubscriptionName if it's unset.
+func (s *subscription) resolve(ctx context.Context, opts *subscriptionOptions) error {
+	if opts.subscriptionID != 0 {
+		return nil
+	}
+	if opts.subscriptionName == "" {
+		return ErrSubscriptionIDIsZero
+	}
+	key := subscriptionCacheKey(opts.namespace, opts.subscriptionName)
+	if value, ok := s.cache.get(key); ok {
+		opts.subscriptionID = value.(ID)
+		return nil
+	}
+	nsID, err := namespaceID(ctx, s.controller, s.cache, opts.namespace)
+	if err != nil {
+		return err
+	}
+	res, err := s.controller.ListSubscription(ctx, &ctrlpb.ListSubscriptionRequest{
+		Name:        opts.subscriptionName,
+		NamespaceId: nsID,
+	})
+	if err != nil {
+		return err
+	}
+	var matched []*metapb.Subscription
+	for _, sub := range res.GetSubscription() {
+		if sub.Name == opts.subscriptionName && sub.NamespaceId == nsID {
+			matched = append(matched, sub)
+		}
+	}
+	switch len(matched) {
+	case 0:
+		return ErrSubscriptionNotFound
+	case 1:
+		opts.subscriptionID = NewID(matched[0].Id)
+		s.cache.set(opts.subscriptionID, key)
+		return nil
+	default:
+		return fmt.Errorf("%w: %d subscriptions are named %s in %s, use WithSubscriptionID",
+			ErrInvalidArguments, len(matched), opts.subscriptionName, opts.namespace)
+	}
+}
+
+// invalidate removes the subscription of the ID from the cache.
+func (s *subscription) invalidate(id ID) {
+	s.cache.invalidateFunc(func(key string, value interface{}) bool {
+		return strings.HasPrefix(key, cacheSubscription) && value == id
+	})
+}
@@ -0,0 +1,152 @@
+// Copyright 2023 Linkall Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vanus
+
+import (
+	// standard libraries.
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	// third-party libraries.
+	v2 "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+)
+
+// NewTypedEvent builds an event whose data is v encoded by the codec, the
+// datacontenttype and dataschema of the event are set accordingly. The source
+// is required by CloudEvents, so the event is invalid without WithEventSource.
+func NewTypedEvent[T any](v T, opts ...TypedOption) (*v2.Event, error) {
+	o := newTypedOptions(opts...)
+	if o.codecErr != nil {
+		return nil, o.codecErr
+	}
+	if o.codec == nil {
+		if _, ok := interface{}(v).(proto.Message); ok {
+			o.codec = ProtobufCodec()
+		} else {
+			o.codec = JSONCodec()
+		}
+	}
+
+	data, err := o.codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	e := v2.NewEvent()
+	if o.id == "" {
+		o.id = uuid.NewString()
+	}
+	e.SetID(o.id)
+	e.SetSource(o.source)
+	if o.eventType == "" {
+		o.eventType = typeName(v)
+	}
+	e.SetType(o.eventType)
+	if o.subject != "" {
+		e.SetSubject(o.subject)
+	}
+	for name, value := range o.extensions {
+		e.SetExtension(name, value)
+	}
+	e.SetDataContentType(o.codec.ContentType())
+	if sc, ok := o.codec.(SchemaCodec); ok {
+		schema, err := sc.DataSchema(v)
+		if err != nil {
+			return nil, err
+		}
+		if schema != "" {
+			e.SetDataSchema(schema)
+		}
+	}
+	e.DataEncoded = data
+	if err = e.Validate(); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// PublishTyped encodes v as an event by NewTypedEvent and publishes it. The
+// source of the event is the name of the eventbus of p unless WithEventSource
+// is set, it's required if p addresses the eventbus by ID.
+func PublishTyped[T any](ctx context.Context, p Publisher, v T, opts ...TypedOption) error {
+	if name := p.Eventbus(); name != "" {
+		opts = append([]TypedOption{WithEventSource(name)}, opts...)
+	}
+	e, err := NewTypedEvent(v, opts...)
+	if err != nil {
+		return err
+	}
+	return p.Publish(ctx, e)
+}
+
+// DecodeData decodes the data of the message into T by the codec registered for
+// its datacontenttype and dataschema.
+func DecodeData[T any](msg Message) (T, error) {
+	return DecodeEventData[T](msg.GetEvent())
+}
+
+// DecodeEventData decodes the data of the event into T by the codec registered
+// for its datacontenttype and dataschema.
+func DecodeEventData[T any](e *v2.Event) (T, error) {
+	var v T
+	contentType := e.DataContentType()
+	if contentType == "" {
+		contentType = ContentTypeJSON
+	}
+	c, err := LookupCodec(contentType, e.DataSchema())
+	if err != nil {
+		return v, err
+	}
+
+	// Allocate the value if T is a pointer, e.g. a proto message.
+	var target interface{} = &v
+	if rv := reflect.ValueOf(&v).Elem(); rv.Kind() == reflect.Ptr {
+		rv.Set(reflect.New(rv.Type().Elem()))
+		target = v
+	}
+	if m, ok := target.(proto.Message); ok && c.ContentType() == ContentTypeProtobuf {
+		// Like anypb, only the last path segment of the type url is the message name.
+		want := string(m.ProtoReflect().Descriptor().FullName())
+		schema := e.DataSchema()
+		if schema != "" && schema[strings.LastIndex(schema, "/")+1:] != want {
+			return v, fmt.Errorf("%w: dataschema is %s, but want %s", ErrInvalidArguments, schema, want)
+		}
+	}
+	if err = c.Unmarshal(e.Data(), target); err != nil {
+		return v, err
+	}
+	return v, nil
+}
+
+func typeName(v interface{}) string {
+	if m, ok := v.(proto.Message); ok {
+		return string(m.ProtoReflect().Descriptor().FullName())
+	}
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil {
+		return ""
+	}
+	if t.PkgPath() == "" {
+		return t.String()
+	}
+	return t.PkgPath() + "." + t.Name()
+}
@@ -18,7 +18,6 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
-	"strconv"
 	stdtime "time"
 
 	v2 "github.com/cloudevents/sdk-go/v2"
@@ -39,12 +38,6 @@ const (
 	timeAttr                   = "time"
 )
 
-var (
-	emptyID = uint64(0)
-	base    = 16
-	bitSize = 64
-)
-
 var (
 	zeroTime   = stdtime.Time{}
 	ErrEmptyID = errors.New("id: empty")
@@ -181,6 +174,11 @@ func FromProto(container *cloudevents.CloudEvent) (*v2.Event, error) {
 		}
 	case *cloudevents.CloudEvent_ProtoData:
 		e.SetDataContentType(ContentTypeProtobuf)
+		// The type url of the Any is the dataschema, it's overridden below if
+		// the dataschema attribute is present.
+		if dt.ProtoData.TypeUrl != "" {
+			e.SetDataSchema(dt.ProtoData.TypeUrl)
+		}
 		e.DataEncoded = dt.ProtoData.Value
 	}
 	for name, value := range container.Attributes {
@@ -205,6 +203,9 @@ func FromProto(container *cloudevents.CloudEvent) (*v2.Event, error) {
 			e.SetExtension(name, v)
 		}
 	}
+	if err := decompressEvent(&e); err != nil {
+		return nil, err
+	}
 	return &e, nil
 }
 
@@ -238,14 +239,3 @@ func valueFrom(attr *cloudevents.CloudEvent_CloudEventAttributeValue) (interface
 	}
 	return types.Validate(v)
 }
-
-func NewIDFromString(id string) (uint64, error) {
-	if id == "" {
-		return emptyID, ErrEmptyID
-	}
-	i, err := strconv.ParseUint(id, base, bitSize)
-	if err != nil {
-		return emptyID, err
-	}
-	return i, nil
-}